@@ -29,26 +29,51 @@ func NewConcurrent(cfg ConcurrentConfig, maxCap int) *Concurrent {
 }
 
 func (c *Concurrent) Borrow(ctx context.Context) (ok bool) {
-	if c.IsAvailable() {
-		atomic.AddUint32(&c.users, 1)
+	if c.TryBorrow() {
 		return true
-	} else {
+	}
+
+	c.locker.Lock()
+	// a slot may have been settled up since TryBorrow, it is checked again
+	// under the locker SettleUp holds
+	if c.TryBorrow() {
+		c.locker.Unlock()
+		return true
+	}
+	ch := make(chan struct{})
+	pushed := c.queue.Push(ch)
+	c.locker.Unlock()
+	if !pushed {
+		return false
+	}
+	select {
+	case <-ch:
+		return true
+	case <-ctx.Done():
+		// SettleUp may have handed the slot over before ch is popped
 		c.locker.Lock()
-		ch := make(chan struct{})
-		c.queue.Push(ch)
+		_, popped := c.queue.Pop(ch)
 		c.locker.Unlock()
-		select {
-		case <-ch:
+		return !popped
+	}
+}
+
+// TryBorrow takes a free slot without waiting, it returns false when all
+// slots are borrowed.
+func (c *Concurrent) TryBorrow() bool {
+	for {
+		users := atomic.LoadUint32(&c.users)
+		if users >= c.capacity {
+			return false
+		}
+		if atomic.CompareAndSwapUint32(&c.users, users, users+1) {
 			return true
-		case <-ctx.Done():
-			c.queue.Pop(ch)
 		}
 	}
-	return
 }
 
 func (c *Concurrent) SettleUp() {
-	if c.users > 0 {
+	if atomic.LoadUint32(&c.users) > 0 {
 		c.locker.Lock()
 		if ch, ok := c.queue.Pull(); ok {
 			close(ch)
@@ -60,5 +85,5 @@ func (c *Concurrent) SettleUp() {
 }
 
 func (c *Concurrent) IsAvailable() bool {
-	return c.users < c.capacity
+	return atomic.LoadUint32(&c.users) < c.capacity
 }
@@ -0,0 +1,78 @@
+// Command confenc encrypts config values into the enc: format resolved by
+// config.Reader.Read.
+//
+//	confenc -genkey > key
+//	CONFIG_SECRET_KEY_FILE=key confenc -value s3cret
+//	echo -n s3cret | CONFIG_SECRET_KEY_FILE=key confenc
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/0wnperception/go-helpers/pkg/config"
+)
+
+func main() {
+	genKey := flag.Bool("genkey", false, "print a new base64 encoded key")
+	value := flag.String("value", "", "value to encrypt, read from stdin when empty")
+	decrypt := flag.Bool("decrypt", false, "decrypt the enc: value instead")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n\nThe key is read from %s or the file in %s.\n\n",
+			os.Args[0], config.SecretKeyEnv, config.SecretKeyFileEnv)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if err := run(*genKey, *decrypt, *value); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(genKey, decrypt bool, value string) error {
+	if genKey {
+		key, err := config.NewSecretKey()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(key)
+
+		return nil
+	}
+
+	key, err := config.LoadSecretKey()
+	if err != nil {
+		return err
+	}
+
+	if value == "" {
+		raw, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read stdin error: %w", err)
+		}
+
+		value = string(raw)
+	}
+
+	var out string
+
+	if decrypt {
+		out, err = config.Decrypt(key, value)
+	} else {
+		out, err = config.Encrypt(key, value)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(out)
+
+	return nil
+}
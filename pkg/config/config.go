@@ -3,6 +3,7 @@ package config
 import (
 	"bytes"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/0wnperception/go-helpers/pkg/checks"
 	"github.com/0wnperception/go-helpers/pkg/slice"
@@ -39,7 +41,15 @@ type cfg struct {
 	configFile  string
 	configPaths []string
 	envPrefix   string
-	rawBytes    []byte
+	environment string
+	configDir   string
+	flags       *flag.FlagSet
+	provenance  *Provenance
+	secretKey   []byte
+
+	// secretFiles are the secret files read by the last Read
+	mu          sync.Mutex
+	secretFiles []string
 }
 
 type Option func(c *cfg)
@@ -85,34 +95,14 @@ func New(ops ...Option) Reader {
 	return c
 }
 
-func (c *cfg) readConfigFile() error {
-	if c.configFile != "" {
-		fn, err := c.findConfigFile()
-		if err != nil {
-			return err
-		}
-
-		in, err := os.ReadFile(fn)
-		if err != nil {
-			return fmt.Errorf("read config file error: %w", err)
-		}
-
-		c.rawBytes = in
-	}
-
-	return nil
-}
-
-func (c *cfg) readConfig(r io.Reader) error {
+func (c *cfg) readConfig(r io.Reader) ([]byte, error) {
 	buf := new(bytes.Buffer)
 
 	if _, err := buf.ReadFrom(r); err != nil {
-		return fmt.Errorf("read config error: %w", err)
+		return nil, fmt.Errorf("read config error: %w", err)
 	}
 
-	c.rawBytes = buf.Bytes()
-
-	return nil
+	return buf.Bytes(), nil
 }
 
 func (c *cfg) Read(cfg any) error {
@@ -128,14 +118,7 @@ func (c *cfg) Read(cfg any) error {
 		return ErrConfigObjectMustBePointer
 	}
 
-	var err error
-
-	if c.r != nil {
-		err = c.readConfig(c.r)
-	} else {
-		err = c.readConfigFile()
-	}
-
+	sources, err := c.readSources()
 	if err != nil {
 		return fmt.Errorf("read config error: %w", err)
 	}
@@ -144,14 +127,78 @@ func (c *cfg) Read(cfg any) error {
 		return fmt.Errorf("set defaults error: %w", err)
 	}
 
-	if err = yaml.Unmarshal(c.rawBytes, cfg); err != nil {
-		return fmt.Errorf("parse config error: %w", err)
+	// the documents are decoded one by one into the same struct, so a later
+	// document overrides the values it sets and keeps the rest
+	names := make([]string, 0, len(sources))
+	bySource := make([]map[string]struct{}, 0, len(sources))
+
+	for _, s := range sources {
+		if err = yaml.Unmarshal(s.data, cfg); err != nil {
+			return fmt.Errorf("parse config %s error: %w", s.name, err)
+		}
+
+		paths, err := setPaths(s.data)
+		if err != nil {
+			return fmt.Errorf("parse config %s error: %w", s.name, err)
+		}
+
+		set := make(map[string]struct{}, len(paths))
+		for _, p := range paths {
+			set[p] = struct{}{}
+		}
+
+		names = append(names, s.name)
+		bySource = append(bySource, set)
+	}
+
+	entries, err := collect(c.envPrefix, cfg)
+	if err != nil {
+		return fmt.Errorf("collect fields error: %w", err)
 	}
 
-	if err = InjectFromEnv(c.envPrefix, cfg); err != nil {
+	env, err := injectFromEnv(c.envPrefix, cfg)
+	if err != nil {
 		return fmt.Errorf("overwrite from env variables error: %w", err)
 	}
 
+	flags, err := c.applyFlags(entries)
+	if err != nil {
+		return fmt.Errorf("overwrite from flags error: %w", err)
+	}
+
+	secretFiles, err := resolveSecrets(cfg, c.secretKey)
+
+	c.mu.Lock()
+	c.secretFiles = secretFiles
+	c.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("resolve secrets error: %w", err)
+	}
+
+	if err = Validate(cfg); err != nil {
+		return fmt.Errorf("validate config error: %w", err)
+	}
+
+	if c.provenance != nil {
+		p := make(Provenance, len(entries))
+
+		for _, e := range entries {
+			switch {
+			case e.path == "":
+				continue
+			case flags[e.path] != "":
+				p[e.path] = SourceFlag + flags[e.path]
+			case env[e.path] != "":
+				p[e.path] = SourceEnv + env[e.path]
+			default:
+				p[e.path] = provenanceOf(e.path, bySource, names)
+			}
+		}
+
+		*c.provenance = p
+	}
+
 	return nil
 }
 
@@ -172,14 +219,14 @@ func stringInSlice(a string, list []string) bool {
 	return slice.Contains(a, list)
 }
 
-func (c *cfg) findConfigFile() (string, error) {
+func (c *cfg) findConfigFile(name string) (string, error) {
 	if len(c.configPaths) == 0 {
-		if _, err := os.Stat(c.configFile); !os.IsNotExist(err) {
-			return c.configFile, nil
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			return name, nil
 		}
 	} else {
 		for _, cp := range c.configPaths {
-			fn := filepath.Join(cp, c.configFile)
+			fn := filepath.Join(cp, name)
 
 			if _, err := os.Stat(fn); !os.IsNotExist(err) {
 				return fn, nil
@@ -187,7 +234,7 @@ func (c *cfg) findConfigFile() (string, error) {
 		}
 	}
 
-	return "", FileNotFoundError{name: c.configFile, locations: fmt.Sprintf("%s", c.configPaths)}
+	return "", FileNotFoundError{name: name, locations: fmt.Sprintf("%s", c.configPaths)}
 }
 
 func absPathify(inPath string) string {
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const descriptionTagName = "description"
+
+// FieldDoc describes a config field.
+type FieldDoc struct {
+	// Path is the YAML path, e.g. db.host, empty for the fields tagged
+	// yaml:"-" which are set only from the env variables.
+	Path string
+	// Env is the env variable overriding the field, AltEnv is the variable
+	// set with the env tag without the prefix.
+	Env    string
+	AltEnv string
+	Type   string
+	// Default is the default tag of the field.
+	Default     string
+	Description string
+
+	value reflect.Value
+}
+
+// Describe returns the fields of the config struct referenced by ptr in the
+// order of declaration. The env names honor WithEncPrefix of the options.
+func Describe(ptr any, opts ...Option) ([]FieldDoc, error) {
+	c := new(cfg)
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	t := reflect.TypeOf(ptr)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, ErrConfigObjectMustBePointer
+	}
+
+	// the sample values are the defaults, not the values of ptr
+	v := reflect.New(t.Elem())
+
+	if err := SetDefaults(v.Interface()); err != nil {
+		return nil, fmt.Errorf("set defaults error: %w", err)
+	}
+
+	entries, err := collect(c.envPrefix, v.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]FieldDoc, 0, len(entries))
+
+	for _, e := range entries {
+		d := FieldDoc{
+			Path:        e.path,
+			Env:         e.key,
+			Type:        e.field.Type().String(),
+			Default:     e.tags.Get(fieldName),
+			Description: e.tags.Get(descriptionTagName),
+			value:       e.field,
+		}
+
+		if d.Default == "-" {
+			d.Default = ""
+		}
+
+		if e.alt != "" && e.alt != e.key {
+			d.AltEnv = e.alt
+		}
+
+		docs = append(docs, d)
+	}
+
+	return docs, nil
+}
+
+// WriteMarkdown writes the table of the fields of the config struct.
+func WriteMarkdown(w io.Writer, ptr any, opts ...Option) error {
+	docs, err := Describe(ptr, opts...)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+
+	b.WriteString("| Path | Env | Type | Default | Description |\n")
+	b.WriteString("|------|-----|------|---------|-------------|\n")
+
+	for _, d := range docs {
+		env := code(d.Env)
+		if d.AltEnv != "" {
+			env += ", " + code(d.AltEnv)
+		}
+
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			code(d.Path), env, code(d.Type), code(d.Default), escapeCell(d.Description))
+	}
+
+	_, err = io.WriteString(w, b.String())
+
+	return err
+}
+
+// WriteSample writes a config.yaml with the default values of the fields, each
+// field is commented with its description and env variable.
+func WriteSample(w io.Writer, ptr any, opts ...Option) error {
+	docs, err := Describe(ptr, opts...)
+	if err != nil {
+		return err
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, d := range docs {
+		if d.Path == "" {
+			continue
+		}
+
+		value, err := sampleValue(d.value)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", d.Path, err)
+		}
+
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: lastSegment(d.Path), HeadComment: sampleComment(d)}
+
+		parent := mappingAt(root, d.Path)
+		parent.Content = append(parent.Content, key, value)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err = enc.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+func sampleValue(v reflect.Value) (*yaml.Node, error) {
+	n := &yaml.Node{}
+
+	var val any = v.Interface()
+
+	// yaml encodes the durations as nanoseconds, Read parses the strings
+	if v.Type() == durationType {
+		val = time.Duration(v.Int()).String()
+	}
+
+	if err := n.Encode(val); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func sampleComment(d FieldDoc) string {
+	var lines []string
+
+	if d.Description != "" {
+		lines = append(lines, d.Description)
+	}
+
+	env := "env: " + d.Env
+	if d.AltEnv != "" {
+		env += ", " + d.AltEnv
+	}
+
+	return strings.Join(append(lines, env), "\n")
+}
+
+// mappingAt returns the mapping holding the path, the mappings of the parent
+// paths are added when missing.
+func mappingAt(root *yaml.Node, path string) *yaml.Node {
+	segments := strings.Split(path, ".")
+	node := root
+
+	for _, s := range segments[:len(segments)-1] {
+		var next *yaml.Node
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == s && node.Content[i+1].Kind == yaml.MappingNode {
+				next = node.Content[i+1]
+
+				break
+			}
+		}
+
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s}, next)
+		}
+
+		node = next
+	}
+
+	return node
+}
+
+func lastSegment(path string) string {
+	return path[strings.LastIndexByte(path, '.')+1:]
+}
+
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return "`" + escapeCell(s) + "`"
+}
+
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type DocConfig struct {
+	General struct {
+		Debug    bool `description:"Enables the debug logs."`
+		GRPCPort int  `yaml:"grpcPort" default:"13001" env:"GRPC_PORT" description:"Port of the gRPC server."`
+	}
+
+	MQTT struct {
+		Host    string        `default:"localhost:1883" description:"Broker address | host:port."`
+		Timeout time.Duration `default:"5s"`
+		Topics  []string      `default:"[\"events\"]"`
+	} `yaml:"mqtt"`
+
+	Token string `yaml:"-" description:"Read only from the env."`
+}
+
+func TestDescribe(t *testing.T) {
+	docs, err := Describe(&DocConfig{}, WithEncPrefix("app"))
+	require.NoError(t, err)
+	require.Len(t, docs, 6)
+
+	require.Equal(t, "general.grpcPort", docs[1].Path)
+	require.Equal(t, "APP_GENERAL_GRPC_PORT", docs[1].Env)
+	require.Equal(t, "GRPC_PORT", docs[1].AltEnv)
+	require.Equal(t, "int", docs[1].Type)
+	require.Equal(t, "13001", docs[1].Default)
+
+	require.Equal(t, "mqtt.timeout", docs[3].Path)
+	require.Equal(t, "APP_MQTT_TIMEOUT", docs[3].Env)
+	require.Equal(t, "time.Duration", docs[3].Type)
+
+	require.Empty(t, docs[5].Path, "the field is out of the YAML")
+	require.Equal(t, "APP_TOKEN", docs[5].Env)
+
+	_, err = Describe(DocConfig{})
+	require.ErrorIs(t, err, ErrConfigObjectMustBePointer)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+
+	require.NoError(t, WriteMarkdown(&b, &DocConfig{}, WithEncPrefix("app")))
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	require.Len(t, lines, 8)
+	require.Equal(t, "| `general.grpcPort` | `APP_GENERAL_GRPC_PORT`, `GRPC_PORT` | `int` | `13001` | Port of the gRPC server. |", lines[3])
+	require.Equal(t, "| `mqtt.host` | `APP_MQTT_HOST` | `string` | `localhost:1883` | Broker address \\| host:port. |", lines[4])
+}
+
+func TestWriteSample(t *testing.T) {
+	var b bytes.Buffer
+
+	require.NoError(t, WriteSample(&b, &DocConfig{}, WithEncPrefix("app")))
+
+	sample := b.String()
+	require.Contains(t, sample, "general:\n  # Enables the debug logs.\n  # env: APP_GENERAL_DEBUG\n  debug: false\n")
+	require.Contains(t, sample, "  # env: APP_MQTT_TIMEOUT\n  timeout: 5s\n")
+	require.NotContains(t, sample, "APP_TOKEN")
+
+	// the sample reads back into the defaults
+	var c DocConfig
+
+	require.NoError(t, New(WithReader(strings.NewReader(sample))).Read(&c))
+
+	require.Equal(t, 13001, c.General.GRPCPort)
+	require.Equal(t, "localhost:1883", c.MQTT.Host)
+	require.Equal(t, 5*time.Second, c.MQTT.Timeout)
+	require.Equal(t, []string{"events"}, c.MQTT.Topics)
+}
@@ -27,12 +27,21 @@ type cfgEntry struct {
 	name  string
 	alt   string
 	key   string
+	path  string
 	field reflect.Value
 	tags  reflect.StructTag
 }
 
-//nolint:gocognit,mnd
 func collect(prefix string, cfgStruct any) ([]cfgEntry, error) {
+	return collectPath(prefix, "", true, cfgStruct)
+}
+
+// collectPath collects the fields of the struct, path is the YAML path of the
+// struct. The fields out of the YAML, tagged yaml:"-" or nested in such a
+// field, get an empty path: they are set only from the env variables.
+//
+//nolint:gocognit,mnd
+func collectPath(prefix, path string, inYAML bool, cfgStruct any) ([]cfgEntry, error) {
 	structVal := reflect.ValueOf(cfgStruct)
 
 	if structVal.Kind() != reflect.Ptr {
@@ -72,6 +81,13 @@ func collect(prefix string, cfgStruct any) ([]cfgEntry, error) {
 
 		entry.key = entry.name
 
+		yamlName, skip := yamlKey(ftype)
+		fieldInYAML := inYAML && !skip
+
+		if fieldInYAML {
+			entry.path = joinPath(path, yamlName)
+		}
+
 		words := splitCamelRegexp.FindAllStringSubmatch(ftype.Name, -1)
 		if len(words) > 0 {
 			var name []string
@@ -106,7 +122,7 @@ func collect(prefix string, cfgStruct any) ([]cfgEntry, error) {
 
 			embeddedPtr := field.Addr().Interface()
 
-			embeddedInfos, err := collect(innerPrefix, embeddedPtr)
+			embeddedInfos, err := collectPath(innerPrefix, entry.path, fieldInYAML, embeddedPtr)
 			if err != nil {
 				return nil, err
 			}
@@ -121,15 +137,28 @@ func collect(prefix string, cfgStruct any) ([]cfgEntry, error) {
 }
 
 func InjectFromEnv(prefix string, cfgStruct any) error {
+	_, err := injectFromEnv(prefix, cfgStruct)
+
+	return err
+}
+
+// injectFromEnv returns the YAML paths of the fields set from the env
+// variables mapped to the names of the variables.
+func injectFromEnv(prefix string, cfgStruct any) (map[string]string, error) {
 	entries, err := collect(prefix, cfgStruct)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	set := make(map[string]string)
+
 	for _, entry := range entries {
-		value, ok := os.LookupEnv(entry.key)
+		key := entry.key
+
+		value, ok := os.LookupEnv(key)
 		if !ok && entry.alt != "" {
-			value, ok = os.LookupEnv(entry.alt)
+			key = entry.alt
+			value, ok = os.LookupEnv(key)
 		}
 
 		if !ok {
@@ -138,11 +167,15 @@ func InjectFromEnv(prefix string, cfgStruct any) error {
 
 		err = setValue(value, entry.field)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		if entry.path != "" {
+			set[entry.path] = key
 		}
 	}
 
-	return nil
+	return set, nil
 }
 
 //nolint:exhaustive
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Prefixes of the secret values resolved by Read in the string fields tagged
+// `secret:"true"`, the values of the other fields are never resolved:
+//   - file:///run/secrets/db is replaced by the content of the file without
+//     the trailing newlines;
+//   - env:DB_PASSWORD is replaced by the value of the env variable;
+//   - enc:BASE64 is decrypted with the secret key, see Encrypt.
+const (
+	SecretFilePrefix = "file://"
+	SecretEnvPrefix  = "env:"
+	EncryptedPrefix  = "enc:"
+
+	// SecretKeyEnv holds the base64 encoded AES key of the encrypted values.
+	SecretKeyEnv = "CONFIG_SECRET_KEY"
+	// SecretKeyFileEnv holds the path to the file with the base64 encoded key.
+	SecretKeyFileEnv = "CONFIG_SECRET_KEY_FILE"
+
+	secretTagName = "secret"
+)
+
+var (
+	ErrNoSecretKey        = errors.New("secret key is not set")
+	ErrInvalidSecretKey   = errors.New("secret key must be 16, 24 or 32 bytes")
+	ErrInvalidEncrypted   = errors.New("invalid encrypted value")
+	ErrSecretEnvNotSet    = errors.New("secret env variable is not set")
+	errSecretKeyMalformed = errors.New("secret key must be base64 encoded")
+)
+
+// WithSecretKey sets the AES key of the encrypted values, by default it is read
+// from CONFIG_SECRET_KEY or the file in CONFIG_SECRET_KEY_FILE.
+func WithSecretKey(key []byte) Option {
+	return func(c *cfg) {
+		c.secretKey = key
+	}
+}
+
+// LoadSecretKey reads the base64 encoded key from CONFIG_SECRET_KEY or the file
+// in CONFIG_SECRET_KEY_FILE.
+func LoadSecretKey() ([]byte, error) {
+	encoded, ok := os.LookupEnv(SecretKeyEnv)
+
+	if !ok {
+		fn, ok := os.LookupEnv(SecretKeyFileEnv)
+		if !ok {
+			return nil, ErrNoSecretKey
+		}
+
+		raw, err := os.ReadFile(fn)
+		if err != nil {
+			return nil, fmt.Errorf("read secret key error: %w", err)
+		}
+
+		encoded = string(raw)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errSecretKeyMalformed
+	}
+
+	return key, nil
+}
+
+// NewSecretKey returns a random 32 bytes key encoded with base64.
+func NewSecretKey() (string, error) {
+	key := make([]byte, 32)
+
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("read random error: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrInvalidSecretKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Encrypt returns the value encrypted with AES-GCM in the enc: format.
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err = rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("read random error: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plaintext of the value in the enc: format.
+func Decrypt(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", ErrInvalidEncrypted
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrInvalidEncrypted
+	}
+
+	return string(plain), nil
+}
+
+type secretResolver struct {
+	key    []byte
+	keyErr error
+	loaded bool
+
+	// files are the secret files read, the watcher checks them for changes
+	files []string
+}
+
+func (r *secretResolver) secretKey() ([]byte, error) {
+	if !r.loaded {
+		r.key, r.keyErr = LoadSecretKey()
+		r.loaded = true
+	}
+
+	return r.key, r.keyErr
+}
+
+// resolve returns the value of the reference, the value itself when it is not
+// a reference.
+func (r *secretResolver) resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, SecretFilePrefix):
+		fn := strings.TrimPrefix(value, SecretFilePrefix)
+		r.files = append(r.files, fn)
+
+		raw, err := os.ReadFile(fn)
+		if err != nil {
+			return "", fmt.Errorf("read secret file error: %w", err)
+		}
+
+		return strings.TrimRight(string(raw), "\r\n"), nil
+	case strings.HasPrefix(value, SecretEnvPrefix):
+		name := strings.TrimPrefix(value, SecretEnvPrefix)
+
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrSecretEnvNotSet, name)
+		}
+
+		return v, nil
+	case strings.HasPrefix(value, EncryptedPrefix):
+		key, err := r.secretKey()
+		if err != nil {
+			return "", err
+		}
+
+		return Decrypt(key, value)
+	default:
+		return value, nil
+	}
+}
+
+// ResolveSecrets replaces the secret references and the encrypted values in the
+// fields of the struct referenced by ptr tagged `secret:"true"`, including the
+// strings in the slices, maps and structs of the tagged fields. The key
+// decrypts the enc: values, when it is nil the key is loaded with
+// LoadSecretKey.
+func ResolveSecrets(ptr any, key []byte) error {
+	_, err := resolveSecrets(ptr, key)
+
+	return err
+}
+
+// resolveSecrets returns the paths of the secret files read.
+func resolveSecrets(ptr any, key []byte) ([]string, error) {
+	v := reflect.ValueOf(ptr)
+
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errInvalidType
+	}
+
+	r := &secretResolver{key: key, loaded: key != nil}
+
+	err := r.resolveValue(v.Elem(), "", false)
+
+	return r.files, err
+}
+
+// resolveValue resolves the strings of v when it is a part of a secret field,
+// the fields of the structs are checked for the tag otherwise.
+//
+// //nolint:exhaustive
+func (r *secretResolver) resolveValue(v reflect.Value, path string, secret bool) error {
+	switch v.Kind() {
+	case reflect.String:
+		if !secret || !v.CanSet() {
+			return nil
+		}
+
+		resolved, err := r.resolve(v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		v.SetString(resolved)
+	case reflect.Struct:
+		t := v.Type()
+
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+
+			fieldPath := path
+			if key, skip := yamlKey(sf); !skip {
+				fieldPath = joinPath(path, key)
+			}
+
+			fieldSecret := secret || sf.Tag.Get(secretTagName) == "true"
+
+			if err := r.resolveValue(v.Field(i), fieldPath, fieldSecret); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return r.resolveValue(v.Elem(), path, secret)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if err := r.resolveValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), secret); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// the map values are not addressable, a copy is resolved and set back
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+
+			if err := r.resolveValue(elem, fmt.Sprintf("%s[%v]", path, iter.Key()), secret); err != nil {
+				return err
+			}
+
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type SecretConfig struct {
+	MQTT struct {
+		User     string
+		Password string `secret:"true"`
+	} `yaml:"mqtt"`
+
+	DB struct {
+		Password string `secret:"true"`
+		Storage  string
+		Mode     string
+	}
+
+	Tokens  []string          `secret:"true"`
+	Headers map[string]string `secret:"true"`
+}
+
+func TestSecrets(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "db"), "db-pass\n")
+
+	key, err := NewSecretKey()
+	require.NoError(t, err)
+
+	t.Setenv(SecretKeyEnv, key)
+	t.Setenv("MQTT_PASS", "mqtt-pass")
+
+	raw, err := LoadSecretKey()
+	require.NoError(t, err)
+
+	enc, err := Encrypt(raw, "token")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(enc, EncryptedPrefix))
+
+	yml := `
+mqtt:
+  user: agent
+  password: env:MQTT_PASS
+db:
+  password: file://` + filepath.Join(dir, "db") + `
+tokens: ["` + enc + `", plain]
+headers:
+  x-token: ` + enc + `
+`
+
+	c := SecretConfig{}
+
+	require.NoError(t, New(WithReader(strings.NewReader(yml))).Read(&c))
+	require.Equal(t, "agent", c.MQTT.User)
+	require.Equal(t, "mqtt-pass", c.MQTT.Password)
+	require.Equal(t, "db-pass", c.DB.Password)
+	require.Equal(t, []string{"token", "plain"}, c.Tokens)
+	require.Equal(t, map[string]string{"x-token": "token"}, c.Headers)
+}
+
+func TestSecretErrors(t *testing.T) {
+	key := make([]byte, 32)
+
+	enc, err := Encrypt(key, "value")
+	require.NoError(t, err)
+
+	c := SecretConfig{}
+	c.DB.Password = enc
+
+	require.NoError(t, ResolveSecrets(&c, key))
+	require.Equal(t, "value", c.DB.Password)
+
+	c.DB.Password = enc
+	require.ErrorIs(t, ResolveSecrets(&c, make([]byte, 10)), ErrInvalidSecretKey)
+
+	other := make([]byte, 32)
+	other[0] = 1
+
+	c.DB.Password = enc
+	err = ResolveSecrets(&c, other)
+	require.ErrorIs(t, err, ErrInvalidEncrypted)
+	require.ErrorContains(t, err, "db.password")
+
+	t.Setenv(SecretKeyEnv, "")
+	os.Unsetenv(SecretKeyEnv)
+
+	c.DB.Password = enc
+	require.ErrorIs(t, ResolveSecrets(&c, nil), ErrNoSecretKey)
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	writeFile(t, keyFile, base64.StdEncoding.EncodeToString(key)+"\n")
+
+	t.Setenv(SecretKeyFileEnv, keyFile)
+
+	c.DB.Password = enc
+	require.NoError(t, ResolveSecrets(&c, nil))
+	require.Equal(t, "value", c.DB.Password)
+
+	c.MQTT.Password = "env:MISSING_SECRET_VAR"
+	require.ErrorIs(t, ResolveSecrets(&c, key), ErrSecretEnvNotSet)
+}
+
+func TestSecretsOptIn(t *testing.T) {
+	yml := `
+db:
+  storage: file:///etc/hostname
+  mode: env:prod
+`
+
+	c := SecretConfig{}
+
+	require.NoError(t, New(WithReader(strings.NewReader(yml))).Read(&c))
+	require.Equal(t, "file:///etc/hostname", c.DB.Storage, "plain values are not resolved")
+	require.Equal(t, "env:prod", c.DB.Mode)
+}
+
+func TestWatcherSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db")
+
+	writeFile(t, secret, "one\n")
+	writeFile(t, filepath.Join(dir, "config.yaml"), "db:\n  password: file://"+secret+"\n")
+
+	w, err := Watch[SecretConfig](New(WithConfigPath(dir)))
+	require.NoError(t, err)
+	require.Equal(t, "one", w.Current().DB.Password)
+	require.Equal(t, w.c.fingerprint(), w.fingerprint, "the secret files are watched from the start")
+
+	var got []string
+
+	w.Subscribe(func(_, new *SecretConfig) {
+		got = append(got, new.DB.Password)
+	})
+
+	w.Check()
+
+	writeFile(t, secret, "rotated\n")
+	w.Check()
+
+	require.Equal(t, []string{"rotated"}, got)
+}
+
+func TestSecretsEnvOnly(t *testing.T) {
+	type envOnly struct {
+		Password string `yaml:"-" env:"APP_SECRET_PW" secret:"true"`
+	}
+
+	t.Setenv("APP_SECRET_PW", "env:APP_SECRET_PW_VALUE")
+	t.Setenv("APP_SECRET_PW_VALUE", "resolved")
+
+	c := envOnly{}
+
+	require.NoError(t, New(WithReader(strings.NewReader("{}\n"))).Read(&c))
+	require.Equal(t, "resolved", c.Password)
+}
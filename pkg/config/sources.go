@@ -0,0 +1,270 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of the config values, the files are reported with their paths, e.g.
+// "file:/etc/app/config.yaml".
+const (
+	SourceDefault = "default"
+	SourceReader  = "reader"
+	SourceFile    = "file:"
+	SourceFlag    = "flag:"
+	SourceEnv     = "env:"
+)
+
+// Provenance maps the YAML path of every config field to the source of its
+// final value, e.g. "db.host" to "env:APP_DB_HOST".
+type Provenance map[string]string
+
+// String returns the report sorted by the paths, a line per field.
+func (p Provenance) String() string {
+	paths := make([]string, 0, len(p))
+
+	for path := range p {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	var b strings.Builder
+
+	for _, path := range paths {
+		fmt.Fprintf(&b, "%s = %s\n", path, p[path])
+	}
+
+	return b.String()
+}
+
+// WithEnvironment reads the overlay of the config file for the environment,
+// e.g. config.prod.yaml for config.yaml, after the config file. The overlay
+// is optional.
+func WithEnvironment(env string) Option {
+	return func(c *cfg) {
+		c.environment = env
+	}
+}
+
+// WithConfigDir reads the *.yaml fragments of the directory, e.g. conf.d, in
+// the lexical order after the config file and its overlay.
+func WithConfigDir(dir string) Option {
+	return func(c *cfg) {
+		if dir != "" {
+			c.configDir = absPathify(dir)
+		}
+	}
+}
+
+// WithFlags sets the fields from the flags of the parsed flag set named after
+// the YAML paths of the fields, e.g. -db.host. The flags are applied last,
+// after the files and the env variables, the flags not set on the command line
+// are ignored.
+func WithFlags(fs *flag.FlagSet) Option {
+	return func(c *cfg) {
+		c.flags = fs
+	}
+}
+
+// WithProvenance fills p with the sources of the field values on every Read.
+func WithProvenance(p *Provenance) Option {
+	return func(c *cfg) {
+		c.provenance = p
+	}
+}
+
+type source struct {
+	name string
+	data []byte
+}
+
+// overlayFile returns the name of the environment overlay of the config file.
+func (c *cfg) overlayFile() string {
+	ext := filepath.Ext(c.configFile)
+
+	return strings.TrimSuffix(c.configFile, ext) + "." + c.environment + ext
+}
+
+// readSources returns the YAML documents in the order of precedence: the
+// config file or reader, the environment overlay and the config dir fragments.
+func (c *cfg) readSources() ([]source, error) {
+	var sources []source
+
+	switch {
+	case c.r != nil:
+		data, err := c.readConfig(c.r)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, source{name: SourceReader, data: data})
+	case c.configFile != "":
+		fn, err := c.findConfigFile(c.configFile)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := readSourceFile(fn)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, s)
+	}
+
+	if c.environment != "" && c.configFile != "" {
+		fn, err := c.findConfigFile(c.overlayFile())
+		if err == nil {
+			s, err := readSourceFile(fn)
+			if err != nil {
+				return nil, err
+			}
+
+			sources = append(sources, s)
+		}
+	}
+
+	if c.configDir != "" {
+		fragments, err := c.readConfigDir()
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, fragments...)
+	}
+
+	return sources, nil
+}
+
+func (c *cfg) readConfigDir() ([]source, error) {
+	entries, err := os.ReadDir(c.configDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read config dir error: %w", err)
+	}
+
+	var sources []source
+
+	// the entries are sorted by name
+	for _, e := range entries {
+		if e.IsDir() || (filepath.Ext(e.Name()) != ".yaml" && filepath.Ext(e.Name()) != ".yml") {
+			continue
+		}
+
+		s, err := readSourceFile(filepath.Join(c.configDir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, s)
+	}
+
+	return sources, nil
+}
+
+func readSourceFile(fn string) (source, error) {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		return source{}, fmt.Errorf("read config file error: %w", err)
+	}
+
+	return source{name: SourceFile + fn, data: data}, nil
+}
+
+// setPaths returns the paths of the values set by the YAML document.
+func setPaths(data []byte) ([]string, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+
+	var walk func(n *yaml.Node, path string)
+
+	walk = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				walk(n.Content[i+1], joinPath(path, n.Content[i].Value))
+			}
+		default:
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	walk(&doc, "")
+
+	return paths, nil
+}
+
+// applyFlags sets the fields from the flags set on the command line and
+// returns their paths mapped to the flag names.
+func (c *cfg) applyFlags(entries []cfgEntry) (map[string]string, error) {
+	set := make(map[string]string)
+
+	if c.flags == nil {
+		return set, nil
+	}
+
+	byPath := make(map[string]cfgEntry, len(entries))
+	for _, e := range entries {
+		if e.path != "" {
+			byPath[e.path] = e
+		}
+	}
+
+	var err error
+
+	c.flags.Visit(func(f *flag.Flag) {
+		e, ok := byPath[f.Name]
+		if !ok || err != nil {
+			return
+		}
+
+		if err = setValue(f.Value.String(), e.field); err != nil {
+			err = fmt.Errorf("flag %s: %w", f.Name, err)
+
+			return
+		}
+
+		set[e.path] = f.Name
+	})
+
+	return set, err
+}
+
+// provenanceOf returns the source of the field: the last source which set
+// the path of the field or a path under it, e.g. a key of a map field.
+func provenanceOf(path string, bySource []map[string]struct{}, names []string) string {
+	for i := len(bySource) - 1; i >= 0; i-- {
+		if _, ok := bySource[i][path]; ok {
+			return names[i]
+		}
+
+		for p := range bySource[i] {
+			if strings.HasPrefix(p, path+".") {
+				return names[i]
+			}
+		}
+	}
+
+	return SourceDefault
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type LayeredConfig struct {
+	General struct {
+		Debug    bool
+		GRPCPort int    `yaml:"grpcPort" default:"13001"`
+		Name     string `default:"agent"`
+		Region   string
+	}
+
+	DB struct {
+		Host     string
+		User     string
+		Timeout  time.Duration
+		Password string `yaml:"-"`
+	}
+
+	Labels map[string]string
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+}
+
+func TestLayeredSources(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "config.yaml"), `
+general:
+  debug: true
+  grpcPort: 8080
+db:
+  host: base
+  user: base
+  timeout: 1s
+labels:
+  a: base
+`)
+	writeFile(t, filepath.Join(dir, "config.prod.yaml"), `
+db:
+  host: prod
+labels:
+  b: prod
+`)
+	writeFile(t, filepath.Join(dir, "conf.d", "20-db.yaml"), "db:\n  user: second\n")
+	writeFile(t, filepath.Join(dir, "conf.d", "10-db.yaml"), "db:\n  user: first\n  timeout: 2s\n")
+	writeFile(t, filepath.Join(dir, "conf.d", "README.md"), "not a fragment")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("general.grpcPort", 0, "")
+	fs.String("db.host", "", "")
+	fs.Bool("verbose", false, "")
+	require.NoError(t, fs.Parse([]string{"-general.grpcPort=9090", "-db.host=flag", "-verbose"}))
+
+	t.Setenv("LAYERED_DB_HOST", "env")
+	t.Setenv("LAYERED_GENERAL_REGION", "env")
+	t.Setenv("LAYERED_DB_PASSWORD", "secret")
+
+	var p Provenance
+
+	c := LayeredConfig{}
+
+	err := New(
+		WithConfigPath(dir),
+		WithEnvironment("prod"),
+		WithConfigDir(filepath.Join(dir, "conf.d")),
+		WithFlags(fs),
+		WithEncPrefix("layered"),
+		WithProvenance(&p),
+	).Read(&c)
+	require.NoError(t, err)
+
+	require.True(t, c.General.Debug)
+	require.Equal(t, 9090, c.General.GRPCPort)
+	require.Equal(t, "agent", c.General.Name)
+	require.Equal(t, "env", c.General.Region)
+	require.Equal(t, "flag", c.DB.Host, "the flags override the env")
+	require.Equal(t, "secret", c.DB.Password)
+	require.Equal(t, "second", c.DB.User)
+	require.Equal(t, 2*time.Second, c.DB.Timeout)
+	require.Equal(t, map[string]string{"a": "base", "b": "prod"}, c.Labels)
+
+	require.Equal(t, Provenance{
+		"general.debug":    SourceFile + filepath.Join(dir, "config.yaml"),
+		"general.grpcPort": SourceFlag + "general.grpcPort",
+		"general.name":     SourceDefault,
+		"general.region":   SourceEnv + "LAYERED_GENERAL_REGION",
+		"db.host":          SourceFlag + "db.host",
+		"db.user":          SourceFile + filepath.Join(dir, "conf.d", "20-db.yaml"),
+		"db.timeout":       SourceFile + filepath.Join(dir, "conf.d", "10-db.yaml"),
+		"labels":           SourceFile + filepath.Join(dir, "config.prod.yaml"),
+	}, p)
+
+	require.Contains(t, p.String(), "general.region = env:LAYERED_GENERAL_REGION\n")
+}
+
+func TestLayeredSourcesOptional(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "config.yaml"), "db:\n  host: base\n")
+
+	c := LayeredConfig{}
+
+	err := New(WithConfigPath(dir), WithEnvironment("dev"), WithConfigDir(filepath.Join(dir, "conf.d"))).Read(&c)
+	require.NoError(t, err)
+	require.Equal(t, "base", c.DB.Host)
+}
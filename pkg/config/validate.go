@@ -0,0 +1,380 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0wnperception/go-helpers/pkg/slice"
+)
+
+const validateTagName = "validate"
+
+var ErrInvalidRule = errors.New("invalid validate rule")
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Validator is an interface for checking a config struct after it is read,
+// the error is reported with the path of the struct.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError is an invalid value of the field with the YAML path.
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists every invalid field of the config.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks the fields of the struct referenced by ptr against their
+// `validate` tags and calls Validate of the structs implementing Validator.
+//
+// The tag holds comma separated rules:
+//   - required: the value is not zero;
+//   - min=N, max=N: the bounds of a number, the length of a string, slice or
+//     map, or of a time.Duration, e.g. min=1s;
+//   - oneof=a b c: the value is one of the space separated values;
+//   - url: an absolute URL with a host;
+//   - hostport: a host and a port, e.g. localhost:5432;
+//   - regex=EXPR: the string matches the expression, it must be the last rule
+//     as the expression may contain commas.
+//
+// Every rule except required and min, max accepts an empty value. All invalid
+// fields are returned in a *ValidationError.
+func Validate(ptr any) error {
+	v := reflect.ValueOf(ptr)
+
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errInvalidType
+	}
+
+	verr := &ValidationError{}
+
+	if err := validateStruct(v.Elem(), "", verr); err != nil {
+		return err
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+func validateStruct(v reflect.Value, path string, verr *ValidationError) error {
+	t := v.Type()
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		// yaml:"-" fields may still be set from env, so they are validated
+		// under the parent path.
+		fieldPath := path
+		if key, skip := yamlKey(sf); !skip {
+			fieldPath = joinPath(path, key)
+		}
+
+		if tag := sf.Tag.Get(validateTagName); tag != "" && tag != "-" {
+			msgs, err := checkRules(v.Field(i), tag)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", fieldPath, err)
+			}
+
+			for _, msg := range msgs {
+				verr.Fields = append(verr.Fields, FieldError{Path: fieldPath, Err: errors.New(msg)})
+			}
+		}
+
+		if err := validateNested(v.Field(i), fieldPath, verr); err != nil {
+			return err
+		}
+	}
+
+	callValidator(v, path, verr)
+
+	return nil
+}
+
+// //nolint:exhaustive
+func validateNested(v reflect.Value, path string, verr *ValidationError) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		return validateStruct(v, path, verr)
+	case reflect.Ptr:
+		if !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+			return validateStruct(v.Elem(), path, verr)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if err := validateNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), verr); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateNested(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), verr); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func callValidator(v reflect.Value, path string, verr *ValidationError) {
+	var val any
+
+	if v.CanAddr() {
+		val = v.Addr().Interface()
+	} else {
+		val = v.Interface()
+	}
+
+	if vd, ok := val.(Validator); ok {
+		if err := vd.Validate(); err != nil {
+			verr.Fields = append(verr.Fields, FieldError{Path: path, Err: err})
+		}
+	}
+}
+
+// checkRules returns the messages of the failed rules, the error is returned
+// for a malformed tag.
+func checkRules(v reflect.Value, tag string) ([]string, error) {
+	var msgs []string
+
+	for tag != "" {
+		var rule string
+
+		if strings.HasPrefix(tag, "regex=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		msg, err := checkRule(v, name, arg)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidRule, rule, err)
+		}
+
+		if msg != "" {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs, nil
+}
+
+//nolint:cyclop
+func checkRule(v reflect.Value, name, arg string) (string, error) {
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return "is required", nil
+		}
+	case "min", "max":
+		return checkBound(v, name, arg)
+	case "oneof":
+		s, err := stringValue(v)
+		if err != nil || s == "" {
+			return "", err
+		}
+
+		if !slice.Contains(s, strings.Fields(arg)) {
+			return fmt.Sprintf("must be one of [%s]", arg), nil
+		}
+	case "url":
+		s, err := stringValue(v)
+		if err != nil || s == "" {
+			return "", err
+		}
+
+		if u, err := url.Parse(s); err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be an absolute URL", nil
+		}
+	case "hostport":
+		s, err := stringValue(v)
+		if err != nil || s == "" {
+			return "", err
+		}
+
+		if !validHostPort(s) {
+			return "must be host:port", nil
+		}
+	case "regex":
+		s, err := stringValue(v)
+		if err != nil {
+			return "", err
+		}
+
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return "", err
+		}
+
+		if s != "" && !re.MatchString(s) {
+			return fmt.Sprintf("must match %s", arg), nil
+		}
+	default:
+		return "", errors.New("unknown rule")
+	}
+
+	return "", nil
+}
+
+// //nolint:exhaustive
+func checkBound(v reflect.Value, name, arg string) (string, error) {
+	var less, greater bool
+
+	switch {
+	case v.Type() == durationType:
+		bound, err := time.ParseDuration(arg)
+		if err != nil {
+			return "", err
+		}
+
+		d := time.Duration(v.Int())
+		less, greater = d < bound, d > bound
+	case v.CanInt():
+		bound, err := strconv.ParseInt(arg, 0, 64)
+		if err != nil {
+			return "", err
+		}
+
+		less, greater = v.Int() < bound, v.Int() > bound
+	case v.CanUint():
+		bound, err := strconv.ParseUint(arg, 0, 64)
+		if err != nil {
+			return "", err
+		}
+
+		less, greater = v.Uint() < bound, v.Uint() > bound
+	case v.CanFloat():
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return "", err
+		}
+
+		less, greater = v.Float() < bound, v.Float() > bound
+	default:
+		switch v.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		default:
+			return "", fmt.Errorf("unsupported type %s", v.Type())
+		}
+
+		bound, err := strconv.Atoi(arg)
+		if err != nil {
+			return "", err
+		}
+
+		if name == "min" && v.Len() < bound {
+			return fmt.Sprintf("length must be at least %d", bound), nil
+		}
+
+		if name == "max" && v.Len() > bound {
+			return fmt.Sprintf("length must be at most %d", bound), nil
+		}
+
+		return "", nil
+	}
+
+	if name == "min" && less {
+		return "must be at least " + arg, nil
+	}
+
+	if name == "max" && greater {
+		return "must be at most " + arg, nil
+	}
+
+	return "", nil
+}
+
+func stringValue(v reflect.Value) (string, error) {
+	if v.Kind() != reflect.String {
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return v.String(), nil
+}
+
+func validHostPort(s string) bool {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil || host == "" {
+		return false
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+
+	return err == nil && p > 0
+}
+
+// yamlKey returns the key of the field in the YAML document, the lowercased
+// field name unless the yaml tag sets one. An inline field has no key.
+func yamlKey(sf reflect.StructField) (key string, skip bool) {
+	tag := sf.Tag.Get("yaml")
+	if tag == "-" {
+		return "", true
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+
+	if strings.Contains(opts, "inline") {
+		return "", false
+	}
+
+	if name == "" {
+		name = strings.ToLower(sf.Name)
+	}
+
+	return name, false
+}
+
+func joinPath(path, key string) string {
+	switch {
+	case key == "":
+		return path
+	case path == "":
+		return key
+	default:
+		return path + "." + key
+	}
+}
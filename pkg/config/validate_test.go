@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type VDB struct {
+	Host              string        `validate:"required,hostport"`
+	ReconnectAttempts int           `yaml:"reconnectAttempts" validate:"min=0,max=10"`
+	Timeout           time.Duration `validate:"min=100ms,max=1m"`
+	Mode              string        `validate:"oneof=ro rw"`
+}
+
+type VBroker struct {
+	URL  string `yaml:"url" validate:"required,url"`
+	User string `validate:"regex=^[a-z]{2,8}$"`
+}
+
+func (b *VBroker) Validate() error {
+	if b.User == "root" {
+		return errors.New("root is not allowed")
+	}
+
+	return nil
+}
+
+type VConfig struct {
+	DB      VDB
+	Brokers []VBroker `validate:"min=1"`
+	Tags    []string  `validate:"max=2"`
+	Ratio   float64   `validate:"min=0,max=1"`
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() VConfig {
+		return VConfig{
+			DB:      VDB{Host: "db:5432", ReconnectAttempts: 3, Timeout: time.Second, Mode: "ro"},
+			Brokers: []VBroker{{URL: "tcp://mqtt:1883", User: "agent"}},
+			Ratio:   0.5,
+		}
+	}
+
+	c := valid()
+	require.NoError(t, Validate(&c))
+
+	c = VConfig{
+		DB:      VDB{Host: "db", ReconnectAttempts: -1, Timeout: time.Hour, Mode: "wo"},
+		Brokers: []VBroker{{URL: "mqtt", User: "Agent"}, {URL: "tcp://mqtt:1883", User: "root"}},
+		Tags:    []string{"a", "b", "c"},
+		Ratio:   2,
+	}
+
+	err := Validate(&c)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+
+	got := make(map[string]string)
+	for _, f := range verr.Fields {
+		got[f.Path] = f.Err.Error()
+	}
+
+	require.Equal(t, map[string]string{
+		"db.host":              "must be host:port",
+		"db.reconnectAttempts": "must be at least 0",
+		"db.timeout":           "must be at most 1m",
+		"db.mode":              "must be one of [ro rw]",
+		"brokers[0].url":       "must be an absolute URL",
+		"brokers[0].user":      "must match ^[a-z]{2,8}$",
+		"brokers[1]":           "root is not allowed",
+		"tags":                 "length must be at most 2",
+		"ratio":                "must be at most 1",
+	}, got)
+
+	c = VConfig{}
+	err = Validate(&c)
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, err.Error(), "db.host: is required")
+	require.Contains(t, err.Error(), "brokers: length must be at least 1")
+	require.Contains(t, err.Error(), "db.timeout: must be at least 100ms")
+	require.NotContains(t, err.Error(), "db.mode", "empty values pass the format rules")
+}
+
+func TestValidateInvalidRule(t *testing.T) {
+	c := struct {
+		Port int `validate:"between=1"`
+	}{}
+
+	require.ErrorIs(t, Validate(&c), ErrInvalidRule)
+
+	d := struct {
+		Port int `validate:"url"`
+	}{Port: 1}
+
+	require.ErrorIs(t, Validate(&d), ErrInvalidRule)
+}
+
+func TestReadValidates(t *testing.T) {
+	c := VConfig{}
+
+	err := New(WithReader(strings.NewReader("db:\n  host: localhost\n"))).Read(&c)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, err.Error(), "db.host: must be host:port")
+}
+
+func TestReadValidatesEnvOnly(t *testing.T) {
+	type envOnly struct {
+		Host     string `validate:"required"`
+		Password string `yaml:"-" env:"APP_PW" validate:"required"`
+	}
+
+	c := envOnly{}
+	err := New(WithReader(strings.NewReader("host: localhost\n"))).Read(&c)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Len(t, verr.Fields, 1)
+
+	t.Setenv("APP_PW", "secret")
+
+	c = envOnly{}
+	require.NoError(t, New(WithReader(strings.NewReader("host: localhost\n"))).Read(&c))
+	require.Equal(t, "secret", c.Password)
+}
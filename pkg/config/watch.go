@@ -0,0 +1,331 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is the default period of checking the config files.
+const DefaultWatchInterval = 5 * time.Second
+
+var ErrWatchUnsupported = errors.New("config reader could not be watched")
+
+type watchConfig struct {
+	interval time.Duration
+	onError  func(error)
+}
+
+type WatchOption func(*watchConfig)
+
+// WithWatchInterval sets the period of checking the config files.
+func WithWatchInterval(d time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		if d > 0 {
+			c.interval = d
+		}
+	}
+}
+
+// WithWatchErrors sets the handler of the errors of reading the changed
+// config, the previous config is kept on an error.
+func WithWatchErrors(f func(error)) WatchOption {
+	return func(c *watchConfig) {
+		c.onError = f
+	}
+}
+
+// Watcher reloads the config of type T when its files change: the config file,
+// its environment overlay, the config dir fragments and the files of the
+// file:// secrets.
+type Watcher[T any] struct {
+	c   *cfg
+	cfg watchConfig
+
+	checkMu sync.Mutex
+
+	mu          sync.Mutex
+	current     *T
+	fingerprint string
+	// failed is the fingerprint of the files which failed to read
+	failed  string
+	subs    map[int]func(old, new *T)
+	nextSub int
+	// pending are the changes not yet delivered to the subscribers, in the
+	// order of the reloads
+	pending   []change[T]
+	notifying bool
+}
+
+type change[T any] struct {
+	old, new *T
+	subs     []func(old, new *T)
+}
+
+// Watch reads the config of type T with the reader returned by New. The reader
+// must read from files, an io.Reader can be consumed only once.
+func Watch[T any](r Reader, opts ...WatchOption) (*Watcher[T], error) {
+	c, ok := r.(*cfg)
+	if !ok || c.r != nil || c.configFile == "" {
+		return nil, ErrWatchUnsupported
+	}
+
+	w := &Watcher[T]{
+		c:    c,
+		cfg:  watchConfig{interval: DefaultWatchInterval, onError: func(error) {}},
+		subs: make(map[int]func(old, new *T)),
+	}
+
+	for _, o := range opts {
+		o(&w.cfg)
+	}
+
+	v := new(T)
+	if err := c.Read(v); err != nil {
+		return nil, err
+	}
+
+	// the secret files are known only after the read
+	w.fingerprint = c.fingerprint()
+	w.current = v
+
+	return w, nil
+}
+
+// Current returns the last read config, it must not be modified.
+func (w *Watcher[T]) Current() *T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.current
+}
+
+// Subscribe calls f with the previous and the new config on every change, the
+// returned func cancels the subscription.
+func (w *Watcher[T]) Subscribe(f func(old, new *T)) (cancel func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := w.nextSub
+	w.nextSub++
+	w.subs[id] = f
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		delete(w.subs, id)
+	}
+}
+
+// OnChange calls f when the value selected from the config changes, e.g. the
+// MQTT settings of the agent config.
+func OnChange[T, V any](w *Watcher[T], selector func(*T) V, f func(old, new V)) (cancel func()) {
+	return w.Subscribe(func(old, new *T) {
+		o, n := selector(old), selector(new)
+
+		if !reflect.DeepEqual(o, n) {
+			f(o, n)
+		}
+	})
+}
+
+// Run checks the config files every interval until the context is done.
+func (w *Watcher[T]) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.cfg.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Check reloads the config if its files have changed since the last check.
+// The subscribers are notified only when the new config is read, valid and
+// differs from the previous one. They are called after the check is done, so
+// they may call Check themselves.
+//
+// The changes are delivered one at a time in the order they were read. While
+// the subscribers are being notified by another call, Check returns at once and
+// that call delivers its change too.
+func (w *Watcher[T]) Check() {
+	w.reload()
+	w.notify()
+}
+
+// notify delivers the pending changes unless another call is delivering them.
+func (w *Watcher[T]) notify() {
+	w.mu.Lock()
+	if w.notifying {
+		w.mu.Unlock()
+
+		return
+	}
+
+	w.notifying = true
+	w.mu.Unlock()
+
+	drained := false
+
+	// a panicking subscriber must not block the next notifications
+	defer func() {
+		if !drained {
+			w.mu.Lock()
+			w.notifying = false
+			w.mu.Unlock()
+		}
+	}()
+
+	for {
+		c, ok := w.nextChange()
+		if !ok {
+			drained = true
+
+			return
+		}
+
+		for _, f := range c.subs {
+			f(c.old, c.new)
+		}
+	}
+}
+
+// nextChange pops the first pending change, the notification ends when there
+// are none.
+func (w *Watcher[T]) nextChange() (change[T], bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 {
+		w.notifying = false
+
+		return change[T]{}, false
+	}
+
+	c := w.pending[0]
+	w.pending = w.pending[1:]
+
+	return c, true
+}
+
+// reload reads the changed config and queues the change for the subscribers. A
+// config which fails to read is reported once, until its files change again.
+func (w *Watcher[T]) reload() {
+	w.checkMu.Lock()
+	defer w.checkMu.Unlock()
+
+	fp := w.c.fingerprint()
+
+	w.mu.Lock()
+	changed := fp != w.fingerprint && fp != w.failed
+	w.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	v := new(T)
+
+	// a file being written may fail to parse, the fingerprint is kept so the
+	// next change of the files is read again
+	if err := w.c.Read(v); err != nil {
+		w.mu.Lock()
+		w.failed = fp
+		w.mu.Unlock()
+
+		w.cfg.onError(fmt.Errorf("reload config error: %w", err))
+
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	old := w.current
+	w.fingerprint = fp
+	w.failed = ""
+
+	if reflect.DeepEqual(old, v) {
+		return
+	}
+
+	w.current = v
+
+	subs := make([]func(old, new *T), 0, len(w.subs))
+	for id := range w.nextSub {
+		if f, ok := w.subs[id]; ok {
+			subs = append(subs, f)
+		}
+	}
+
+	w.pending = append(w.pending, change[T]{old: old, new: v, subs: subs})
+}
+
+// watchedFiles returns the config file, its overlay, the config dir and the
+// secret files read by the last Read, the missing ones are included, so their
+// creation is noticed.
+func (c *cfg) watchedFiles() []string {
+	var files []string
+
+	names := []string{c.configFile}
+	if c.environment != "" {
+		names = append(names, c.overlayFile())
+	}
+
+	for _, name := range names {
+		if len(c.configPaths) == 0 {
+			files = append(files, name)
+
+			continue
+		}
+
+		for _, cp := range c.configPaths {
+			files = append(files, filepath.Join(cp, name))
+		}
+	}
+
+	if c.configDir != "" {
+		files = append(files, c.configDir)
+
+		if entries, err := os.ReadDir(c.configDir); err == nil {
+			for _, e := range entries {
+				files = append(files, filepath.Join(c.configDir, e.Name()))
+			}
+		}
+	}
+
+	c.mu.Lock()
+	files = append(files, c.secretFiles...)
+	c.mu.Unlock()
+
+	return files
+}
+
+// fingerprint describes the state of the watched files by their sizes and
+// modification times.
+func (c *cfg) fingerprint() string {
+	files := c.watchedFiles()
+	sort.Strings(files)
+
+	var b strings.Builder
+
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", f, fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+
+	return b.String()
+}
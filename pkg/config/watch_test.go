@@ -0,0 +1,222 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type WatchedConfig struct {
+	Level string `validate:"oneof=debug info"`
+	MQTT  struct {
+		Host string
+		Port int
+	} `yaml:"mqtt"`
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+
+	writeFile(t, file, "level: info\nmqtt:\n  host: a\n")
+
+	var errs []error
+
+	w, err := Watch[WatchedConfig](New(WithConfigPath(dir)), WithWatchErrors(func(err error) {
+		errs = append(errs, err)
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "info", w.Current().Level)
+
+	type change struct{ old, new string }
+
+	var levels, hosts []change
+
+	w.Subscribe(func(old, new *WatchedConfig) {
+		levels = append(levels, change{old.Level, new.Level})
+	})
+	cancel := OnChange(w, func(c *WatchedConfig) string { return c.MQTT.Host }, func(old, new string) {
+		hosts = append(hosts, change{old, new})
+	})
+
+	w.Check()
+	require.Empty(t, levels, "files are not changed")
+
+	writeFile(t, file, "level: debug\nmqtt:\n  host: a\n")
+	w.Check()
+	require.Equal(t, []change{{"info", "debug"}}, levels)
+	require.Empty(t, hosts)
+
+	writeFile(t, file, "# comment\nlevel: debug\nmqtt:\n  host: a\n")
+	w.Check()
+	require.Len(t, levels, 1, "the config is not changed")
+
+	writeFile(t, file, "level: debug\nmqtt:\n  host: [b\n")
+	w.Check()
+	require.Len(t, errs, 1)
+
+	w.Check()
+	require.Len(t, errs, 1, "the failed files are reported once")
+
+	writeFile(t, file, "level: trace\nmqtt:\n  host: b\n")
+	w.Check()
+	require.Len(t, errs, 2)
+	require.ErrorContains(t, errs[1], "level: must be one of")
+	require.Equal(t, "debug", w.Current().Level)
+
+	writeFile(t, file, "level: debug\nmqtt:\n  host: b\n")
+	w.Check()
+	require.Equal(t, change{"debug", "debug"}, levels[1])
+	require.Equal(t, []change{{"a", "b"}}, hosts)
+
+	cancel()
+
+	writeFile(t, file, "level: debug\nmqtt:\n  host: c\n")
+	w.Check()
+	require.Len(t, hosts, 1, "canceled")
+	require.Equal(t, "c", w.Current().MQTT.Host)
+}
+
+func TestWatcherRun(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "config.yaml"), "level: info\n")
+
+	w, err := Watch[WatchedConfig](New(WithConfigPath(dir), WithConfigDir(filepath.Join(dir, "conf.d"))),
+		WithWatchInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+
+	var got []string
+
+	w.Subscribe(func(_, new *WatchedConfig) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		got = append(got, new.MQTT.Host)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() { done <- w.Run(ctx) }()
+
+	// a new fragment is noticed
+	writeFile(t, filepath.Join(dir, "conf.d", "mqtt.yaml"), "mqtt:\n  host: fragment\n")
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(got) == 1 && got[0] == "fragment"
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatcherCheckFromSubscriber(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+
+	writeFile(t, file, "level: info\n")
+
+	w, err := Watch[WatchedConfig](New(WithConfigPath(dir)))
+	require.NoError(t, err)
+
+	var got []string
+
+	w.Subscribe(func(_, new *WatchedConfig) {
+		got = append(got, new.Level)
+		w.Check()
+	})
+
+	writeFile(t, file, "level: debug\n")
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		w.Check()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Check called from a subscriber deadlocked")
+	}
+
+	require.Equal(t, []string{"debug"}, got)
+}
+
+func TestWatcherNotificationOrder(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+
+	writeFile(t, file, "level: info\n")
+
+	w, err := Watch[WatchedConfig](New(WithConfigPath(dir)))
+	require.NoError(t, err)
+
+	entered, release := make(chan struct{}), make(chan struct{})
+
+	var got []string
+
+	w.Subscribe(func(old, new *WatchedConfig) {
+		got = append(got, old.Level+"->"+new.Level)
+
+		if len(got) == 1 {
+			close(entered)
+			<-release
+		}
+	})
+
+	writeFile(t, file, "level: debug\n")
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		w.Check()
+	}()
+
+	<-entered
+
+	writeFile(t, file, "level: info\n")
+
+	checked := make(chan struct{})
+
+	go func() {
+		defer close(checked)
+
+		w.Check()
+	}()
+
+	select {
+	case <-checked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Check waited for the notification of another call")
+	}
+
+	close(release)
+	<-done
+
+	require.Equal(t, []string{"info->debug", "debug->info"}, got, "the changes are delivered in order")
+}
+
+func TestWatchUnsupported(t *testing.T) {
+	_, err := Watch[WatchedConfig](New(WithReader(strings.NewReader(""))))
+	require.ErrorIs(t, err, ErrWatchUnsupported)
+
+	_, err = Watch[WatchedConfig](New(WithConfigFile(filepath.Join(os.TempDir(), "missing.yaml"))))
+	require.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package grpcmidwr
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+const accessLogMessage = "access"
+
+// accessLogContext returns the context with the logger of the request, the
+// logger of logCtx with the request id when the log interceptor has not set
+// one.
+func accessLogContext(logCtx, ctx context.Context) context.Context {
+	if log.FromContext(ctx) != log.Nop() {
+		return ctx
+	}
+
+	ctx = log.FromContext(logCtx).Inject(ctx)
+
+	if id, ok := RequestIDFromContext(ctx); ok {
+		ctx = log.WithFields(ctx, log.String(requestIDField, id))
+	}
+
+	return ctx
+}
+
+func accessFields(method, peer string, start time.Time, err error) []log.Field {
+	fields := []log.Field{
+		log.String("method", method),
+		log.String("peer", peer),
+		log.String("code", status.Code(err).String()),
+		log.Duration("duration", time.Since(start)),
+	}
+
+	if err != nil {
+		fields = append(fields, log.Error(err))
+	}
+
+	return fields
+}
+
+// AccessLogUnaryServerInterceptor logs one line per call. Put it after the
+// request id interceptors, so the line carries the request id.
+func AccessLogUnaryServerInterceptor(logCtx context.Context) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		log.Info(accessLogContext(logCtx, ctx), accessLogMessage, accessFields(info.FullMethod, consumerAddr(ctx), start, err)...)
+
+		return resp, err
+	}
+}
+
+// AccessLogStreamServerInterceptor logs one line per stream with the numbers
+// of the received and sent messages.
+func AccessLogStreamServerInterceptor(logCtx context.Context) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		counted := &countingServerStream{ServerStream: ss}
+
+		err := handler(srv, counted)
+
+		ctx := ss.Context()
+
+		fields := append(accessFields(info.FullMethod, consumerAddr(ctx), start, err),
+			log.Uint64("msgs_received", counted.received.Load()),
+			log.Uint64("msgs_sent", counted.sent.Load()),
+		)
+
+		log.Info(accessLogContext(logCtx, ctx), accessLogMessage, fields...)
+
+		return err
+	}
+}
+
+func WithAccessLogInterceptors(logCtx context.Context) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(AccessLogUnaryServerInterceptor(logCtx)),
+		grpc.ChainStreamInterceptor(AccessLogStreamServerInterceptor(logCtx)),
+	}
+}
+
+type countingServerStream struct {
+	grpc.ServerStream
+
+	received atomic.Uint64
+	sent     atomic.Uint64
+}
+
+func (s *countingServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+
+	return err
+}
+
+func (s *countingServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+
+	return err
+}
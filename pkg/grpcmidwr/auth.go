@@ -0,0 +1,256 @@
+package grpcmidwr
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+const (
+	SchemeBearer = "bearer"
+	SchemeAPIKey = "apikey"
+
+	AuthorizationHeader = "authorization"
+	APIKeyHeader        = "x-api-key"
+
+	principalField = "principal"
+)
+
+var (
+	// ErrInvalidCredentials is returned by the verifiers for unknown or
+	// malformed credentials, the client gets codes.Unauthenticated.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	errMissingCredentials = status.Error(codes.Unauthenticated, "missing credentials")
+	errUnauthenticated    = status.Error(codes.Unauthenticated, "invalid credentials")
+)
+
+// Credentials are read from the request metadata: a bearer token from the
+// authorization header or an API key from the x-api-key header.
+type Credentials struct {
+	Scheme string
+	Token  string
+}
+
+// Principal is the authenticated caller.
+type Principal struct {
+	Subject string
+	Claims  map[string]any
+}
+
+// Verifier authenticates the credentials. A verifier returns a status error
+// to send the code to the client, e.g. codes.PermissionDenied, any other error
+// is sent as codes.Unauthenticated without details.
+type Verifier interface {
+	Verify(ctx context.Context, c Credentials) (*Principal, error)
+}
+
+type VerifierFunc func(ctx context.Context, c Credentials) (*Principal, error)
+
+func (f VerifierFunc) Verify(ctx context.Context, c Credentials) (*Principal, error) {
+	return f(ctx, c)
+}
+
+// Verifiers tries the verifiers in order until one accepts the credentials,
+// nil verifiers are skipped. When all of them reject the credentials the first
+// error other than ErrInvalidCredentials is returned, e.g. ErrTokenExpired.
+func Verifiers(vs ...Verifier) Verifier {
+	return VerifierFunc(func(ctx context.Context, c Credentials) (*Principal, error) {
+		var res error
+
+		for _, v := range vs {
+			if v == nil {
+				continue
+			}
+
+			p, err := v.Verify(ctx, c)
+			if err == nil {
+				return p, nil
+			}
+
+			if res == nil && !errors.Is(err, ErrInvalidCredentials) {
+				res = err
+			}
+		}
+
+		if res == nil {
+			res = ErrInvalidCredentials
+		}
+
+		return nil, res
+	})
+}
+
+// StaticKeys accepts the API keys or bearer tokens from the map of keys to
+// subjects.
+type StaticKeys map[string]string
+
+func (s StaticKeys) Verify(_ context.Context, c Credentials) (*Principal, error) {
+	for key, subject := range s {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(c.Token)) == 1 {
+			return &Principal{Subject: subject}, nil
+		}
+	}
+
+	return nil, ErrInvalidCredentials
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a context carrying the principal.
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal put by the auth interceptors.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+
+	return p, ok
+}
+
+type authConfig struct {
+	verifier     Verifier
+	public       map[string]struct{}
+	apiKeyHeader string
+}
+
+type AuthOption func(*authConfig)
+
+// WithPublicMethods disables the authentication of the methods, e.g.
+// "/pkg.Service/Method", or of all methods of a service, e.g. "/pkg.Service/".
+func WithPublicMethods(methods ...string) AuthOption {
+	return func(c *authConfig) {
+		for _, m := range methods {
+			c.public[m] = struct{}{}
+		}
+	}
+}
+
+// WithAPIKeyHeader sets the metadata key of the API key, x-api-key by default.
+func WithAPIKeyHeader(name string) AuthOption {
+	return func(c *authConfig) {
+		c.apiKeyHeader = strings.ToLower(name)
+	}
+}
+
+func newAuthConfig(v Verifier, opts []AuthOption) *authConfig {
+	c := &authConfig{
+		verifier:     v,
+		public:       make(map[string]struct{}),
+		apiKeyHeader: APIKeyHeader,
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	return c
+}
+
+func (c *authConfig) isPublic(fullMethod string) bool {
+	if _, ok := c.public[fullMethod]; ok {
+		return true
+	}
+
+	if i := strings.LastIndexByte(fullMethod, '/'); i > 0 {
+		_, ok := c.public[fullMethod[:i+1]]
+
+		return ok
+	}
+
+	return false
+}
+
+func (c *authConfig) credentials(ctx context.Context) (Credentials, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if v := md.Get(AuthorizationHeader); len(v) > 0 {
+		scheme, token, ok := strings.Cut(v[0], " ")
+		if ok && strings.EqualFold(scheme, SchemeBearer) && token != "" {
+			return Credentials{Scheme: SchemeBearer, Token: strings.TrimSpace(token)}, true
+		}
+	}
+
+	if v := md.Get(c.apiKeyHeader); len(v) > 0 && v[0] != "" {
+		return Credentials{Scheme: SchemeAPIKey, Token: v[0]}, true
+	}
+
+	return Credentials{}, false
+}
+
+// authenticate returns the context with the principal and its log field.
+func (c *authConfig) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if c.isPublic(fullMethod) {
+		return ctx, nil
+	}
+
+	creds, ok := c.credentials(ctx)
+	if !ok {
+		return nil, errMissingCredentials
+	}
+
+	p, err := c.verifier.Verify(ctx, creds)
+	if err == nil && p == nil {
+		err = ErrInvalidCredentials
+	}
+
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+
+		log.Debug(ctx, "authentication failed", log.String("method", fullMethod), log.Error(err))
+
+		return nil, errUnauthenticated
+	}
+
+	ctx = ContextWithPrincipal(ctx, p)
+
+	return log.WithFields(ctx, log.String(principalField, p.Subject)), nil
+}
+
+// AuthUnaryServerInterceptor authenticates the calls with the verifier.
+func AuthUnaryServerInterceptor(v Verifier, opts ...AuthOption) grpc.UnaryServerInterceptor {
+	cfg := newAuthConfig(v, opts)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := cfg.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamServerInterceptor authenticates the streams with the verifier.
+func AuthStreamServerInterceptor(v Verifier, opts ...AuthOption) grpc.StreamServerInterceptor {
+	cfg := newAuthConfig(v, opts)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := cfg.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// WithAuthInterceptors chains the auth interceptors. Put them after
+// WithLogInterceptors, so the logs carry the principal.
+func WithAuthInterceptors(v Verifier, opts ...AuthOption) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(AuthUnaryServerInterceptor(v, opts...)),
+		grpc.ChainStreamInterceptor(AuthStreamServerInterceptor(v, opts...)),
+	}
+}
@@ -0,0 +1,230 @@
+package grpcmidwr
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+	"github.com/0wnperception/go-helpers/pkg/log/logtest"
+)
+
+var testSecret = []byte("secret")
+
+func signHS256(t *testing.T, secret []byte, claims map[string]any) string {
+	t.Helper()
+
+	enc := func(v any) string {
+		raw, err := json.Marshal(v)
+		require.NoError(t, err)
+
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+
+	unsigned := enc(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + enc(claims)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func callAuth(t *testing.T, interceptor grpc.UnaryServerInterceptor, ctx context.Context, method string) (context.Context, error) {
+	t.Helper()
+
+	var got context.Context
+
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			got = ctx
+
+			return nil, nil
+		})
+
+	return got, err
+}
+
+func TestAuthUnary(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+
+	interceptor := AuthUnaryServerInterceptor(
+		Verifiers(StaticKeys{"key-1": "robot"}, NewHMACJWT(testSecret)),
+		WithPublicMethods("/svc/Public", "/open.Service/"),
+	)
+
+	incoming := func(kv ...string) context.Context {
+		return metadata.NewIncomingContext(rec.Inject(context.Background()), metadata.Pairs(kv...))
+	}
+
+	ctx, err := callAuth(t, interceptor, incoming(APIKeyHeader, "key-1"), "/svc/Call")
+	require.NoError(t, err)
+
+	p, ok := PrincipalFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, "robot", p.Subject)
+
+	log.Info(ctx, "handled")
+	require.Equal(t, 1, rec.Entries().Field("principal", "robot").Len())
+
+	token := signHS256(t, testSecret, map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	ctx, err = callAuth(t, interceptor, incoming(AuthorizationHeader, "Bearer "+token), "/svc/Call")
+	require.NoError(t, err)
+
+	p, _ = PrincipalFromContext(ctx)
+	require.Equal(t, "user-1", p.Subject)
+	require.Equal(t, "user-1", p.Claims["sub"])
+
+	_, err = callAuth(t, interceptor, incoming(APIKeyHeader, "wrong"), "/svc/Call")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Equal(t, "invalid credentials", status.Convert(err).Message())
+
+	_, err = callAuth(t, interceptor, incoming(), "/svc/Call")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Equal(t, "missing credentials", status.Convert(err).Message())
+
+	for _, method := range []string{"/svc/Public", "/open.Service/Any"} {
+		ctx, err = callAuth(t, interceptor, incoming(), method)
+		require.NoError(t, err)
+
+		_, ok = PrincipalFromContext(ctx)
+		require.False(t, ok)
+	}
+}
+
+func TestAuthVerifierStatus(t *testing.T) {
+	interceptor := AuthUnaryServerInterceptor(VerifierFunc(func(context.Context, Credentials) (*Principal, error) {
+		return nil, status.Error(codes.PermissionDenied, "banned")
+	}))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(APIKeyHeader, "key"))
+
+	_, err := callAuth(t, interceptor, ctx, "/svc/Call")
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestVerifiers(t *testing.T) {
+	denied := status.Error(codes.PermissionDenied, "banned")
+
+	reject := func(err error) Verifier {
+		return VerifierFunc(func(context.Context, Credentials) (*Principal, error) {
+			return nil, err
+		})
+	}
+
+	creds := Credentials{Scheme: SchemeAPIKey, Token: "key-1"}
+
+	p, err := Verifiers(nil, reject(ErrInvalidCredentials), StaticKeys{"key-1": "robot"}).Verify(context.Background(), creds)
+	require.NoError(t, err)
+	require.Equal(t, "robot", p.Subject)
+
+	_, err = Verifiers(StaticKeys{}, reject(denied), reject(ErrInvalidCredentials)).Verify(context.Background(), creds)
+	require.Equal(t, codes.PermissionDenied, status.Code(err), "the first meaningful error is kept")
+
+	_, err = Verifiers(nil, StaticKeys{}).Verify(context.Background(), creds)
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = Verifiers().Verify(context.Background(), creds)
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	expired := VerifierFunc(func(context.Context, Credentials) (*Principal, error) {
+		return nil, ErrTokenExpired
+	})
+
+	_, err = Verifiers(StaticKeys{}, expired).Verify(context.Background(), creds)
+	require.ErrorIs(t, err, ErrTokenExpired, "the expiry is kept over invalid credentials")
+}
+
+func TestAuthNilPrincipal(t *testing.T) {
+	interceptor := AuthUnaryServerInterceptor(VerifierFunc(func(context.Context, Credentials) (*Principal, error) {
+		return nil, nil //nolint:nilnil
+	}))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(APIKeyHeader, "key"))
+
+	_, err := callAuth(t, interceptor, ctx, "/svc/Call")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestHMACJWT(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	v := NewHMACJWT(testSecret, WithIssuer("auth"), WithAudience("api"), WithLeeway(time.Minute))
+	v.now = func() time.Time { return now }
+
+	verify := func(secret []byte, claims map[string]any) error {
+		_, err := v.Verify(context.Background(), Credentials{Scheme: SchemeBearer, Token: signHS256(t, secret, claims)})
+
+		return err
+	}
+
+	valid := func() map[string]any {
+		return map[string]any{"sub": "u", "iss": "auth", "aud": []string{"web", "api"}, "exp": now.Unix() + 60}
+	}
+
+	require.NoError(t, verify(testSecret, valid()))
+	require.ErrorIs(t, verify([]byte("other"), valid()), ErrInvalidCredentials)
+
+	claims := valid()
+	claims["exp"] = now.Unix() - 30
+	require.NoError(t, verify(testSecret, claims), "within leeway")
+
+	claims["exp"] = now.Unix() - 120
+	require.ErrorIs(t, verify(testSecret, claims), ErrTokenExpired)
+	require.NotErrorIs(t, verify(testSecret, claims), ErrInvalidCredentials)
+
+	claims = valid()
+	claims["nbf"] = now.Unix() + 120
+	require.ErrorIs(t, verify(testSecret, claims), ErrTokenNotValidYet)
+
+	claims = valid()
+	delete(claims, "exp")
+	require.ErrorIs(t, verify(testSecret, claims), ErrInvalidCredentials)
+	require.ErrorContains(t, verify(testSecret, claims), "exp")
+
+	claims = valid()
+	claims["iss"] = "other"
+	require.ErrorContains(t, verify(testSecret, claims), "issuer")
+
+	claims = valid()
+	claims["aud"] = "web"
+	require.ErrorContains(t, verify(testSecret, claims), "audience")
+
+	_, err := v.Verify(context.Background(), Credentials{Scheme: SchemeAPIKey, Token: signHS256(t, testSecret, valid())})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = v.Verify(context.Background(), Credentials{Scheme: SchemeBearer, Token: "a.b"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthInterceptors(t *testing.T) {
+	conn := startHealthServer(t, WithAuthInterceptors(StaticKeys{"key-1": "robot"},
+		WithPublicMethods("/grpc.health.v1.Health/Watch")), nil)
+	client := healthpb.NewHealthClient(conn)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), APIKeyHeader, "key-1")
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.NoError(t, err)
+}
@@ -0,0 +1,183 @@
+package grpcmidwr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+// errInsufficientDeadline is returned for the calls which can not complete
+// within the remaining deadline.
+var errInsufficientDeadline = status.Error(codes.DeadlineExceeded, "insufficient deadline")
+
+type deadlineConfig struct {
+	def          time.Duration
+	max          map[string]time.Duration
+	minRemaining time.Duration
+}
+
+type DeadlineOption func(*deadlineConfig)
+
+// WithDefaultTimeout sets the timeout of the unary calls without a deadline.
+// The streams are often long-lived, e.g. subscriptions, so they get a timeout
+// only from WithMaxTimeout of their method or service.
+func WithDefaultTimeout(d time.Duration) DeadlineOption {
+	return func(c *deadlineConfig) {
+		c.def = d
+	}
+}
+
+// WithMaxTimeout caps the deadline of a method, e.g. "/pkg.Service/Method",
+// or of all methods of a service, e.g. "/pkg.Service/". The calls of the
+// method without a deadline get the timeout too.
+func WithMaxTimeout(method string, d time.Duration) DeadlineOption {
+	return func(c *deadlineConfig) {
+		c.max[method] = d
+	}
+}
+
+// WithMinRemaining rejects the calls with less time left until the deadline
+// with codes.DeadlineExceeded.
+func WithMinRemaining(d time.Duration) DeadlineOption {
+	return func(c *deadlineConfig) {
+		c.minRemaining = d
+	}
+}
+
+func newDeadlineConfig(opts []DeadlineOption) *deadlineConfig {
+	c := &deadlineConfig{max: make(map[string]time.Duration)}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	return c
+}
+
+// maxTimeout returns the cap of the method, then of its service.
+func (c *deadlineConfig) maxTimeout(fullMethod string) time.Duration {
+	if d, ok := c.max[fullMethod]; ok {
+		return d
+	}
+
+	if i := strings.LastIndexByte(fullMethod, '/'); i > 0 {
+		return c.max[fullMethod[:i+1]]
+	}
+
+	return 0
+}
+
+// apply returns the context bounded by the timeout of the method, the cancel
+// func must be called when the call is done. The default timeout is applied
+// to the unary calls only.
+func (c *deadlineConfig) apply(ctx context.Context, fullMethod string, stream bool) (context.Context, context.CancelFunc, error) {
+	timeout := c.maxTimeout(fullMethod)
+
+	deadline, ok := ctx.Deadline()
+
+	switch {
+	case !ok && timeout == 0 && !stream:
+		timeout = c.def
+	case ok && timeout > 0 && time.Until(deadline) <= timeout:
+		timeout = 0
+	}
+
+	if ok && c.minRemaining > 0 && time.Until(deadline) < c.minRemaining {
+		return nil, nil, errInsufficientDeadline
+	}
+
+	if timeout <= 0 {
+		return ctx, func() {}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	return ctx, cancel, nil
+}
+
+// logTimeout logs the call which failed after its deadline had passed.
+func logTimeout(logCtx, ctx context.Context, method string, start time.Time, err error) {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) && status.Code(err) != codes.DeadlineExceeded {
+		return
+	}
+
+	// the logger of the request is set when the log interceptor is chained
+	// before the deadline one
+	if log.FromContext(ctx) == log.Nop() {
+		ctx = log.FromContext(logCtx).Inject(ctx)
+	}
+
+	fields := []log.Field{
+		log.String("method", method),
+		log.Duration("elapsed", time.Since(start)),
+	}
+
+	if err != nil {
+		fields = append(fields, log.Error(err))
+	}
+
+	log.Warn(ctx, "deadline exceeded", fields...)
+}
+
+// DeadlineUnaryServerInterceptor bounds the calls with the configured
+// timeouts, so the downstream calls made with the request context are bounded
+// too.
+func DeadlineUnaryServerInterceptor(logCtx context.Context, opts ...DeadlineOption) grpc.UnaryServerInterceptor {
+	cfg := newDeadlineConfig(opts)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		callCtx, cancel, err := cfg.apply(ctx, info.FullMethod, false)
+		if err != nil {
+			logTimeout(logCtx, ctx, info.FullMethod, start, err)
+
+			return nil, err
+		}
+		defer cancel()
+
+		resp, err := handler(callCtx, req)
+
+		logTimeout(logCtx, callCtx, info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// DeadlineStreamServerInterceptor bounds the streams with the timeouts set by
+// WithMaxTimeout, the default timeout does not apply to them.
+func DeadlineStreamServerInterceptor(logCtx context.Context, opts ...DeadlineOption) grpc.StreamServerInterceptor {
+	cfg := newDeadlineConfig(opts)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		ctx, cancel, err := cfg.apply(ss.Context(), info.FullMethod, true)
+		if err != nil {
+			logTimeout(logCtx, ss.Context(), info.FullMethod, start, err)
+
+			return err
+		}
+		defer cancel()
+
+		err = handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+
+		logTimeout(logCtx, ctx, info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+func WithDeadlineInterceptors(logCtx context.Context, opts ...DeadlineOption) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(DeadlineUnaryServerInterceptor(logCtx, opts...)),
+		grpc.ChainStreamInterceptor(DeadlineStreamServerInterceptor(logCtx, opts...)),
+	}
+}
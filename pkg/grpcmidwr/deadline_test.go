@@ -0,0 +1,140 @@
+package grpcmidwr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/0wnperception/go-helpers/pkg/log/logtest"
+)
+
+func TestDeadlineUnary(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+
+	interceptor := DeadlineUnaryServerInterceptor(rec.Inject(context.Background()),
+		WithDefaultTimeout(time.Minute),
+		WithMaxTimeout("/svc/Fast", time.Second),
+		WithMaxTimeout("/bounded/", 2*time.Second),
+		WithMinRemaining(50*time.Millisecond),
+	)
+
+	remaining := func(ctx context.Context, method string) (time.Duration, error) {
+		var left time.Duration
+
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(ctx context.Context, _ interface{}) (interface{}, error) {
+				deadline, ok := ctx.Deadline()
+				require.True(t, ok)
+
+				left = time.Until(deadline)
+
+				return nil, nil
+			})
+
+		return left, err
+	}
+
+	left, err := remaining(context.Background(), "/svc/Call")
+	require.NoError(t, err)
+	require.InDelta(t, time.Minute, left, float64(time.Second), "default timeout")
+
+	left, _ = remaining(context.Background(), "/svc/Fast")
+	require.InDelta(t, time.Second, left, float64(100*time.Millisecond), "max timeout without deadline")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	left, _ = remaining(ctx, "/bounded/Call")
+	require.InDelta(t, 2*time.Second, left, float64(100*time.Millisecond), "deadline capped")
+
+	short, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	left, _ = remaining(short, "/bounded/Call")
+	require.Less(t, left, 500*time.Millisecond, "shorter deadline kept")
+
+	tiny, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = remaining(tiny, "/svc/Call")
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	require.Equal(t, 1, rec.Entries().Message("deadline exceeded").Field("method", "/svc/Call").Len())
+}
+
+func TestDeadlineTimeoutLogged(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+
+	interceptor := DeadlineUnaryServerInterceptor(rec.Inject(context.Background()),
+		WithMaxTimeout("/svc/Slow", 20*time.Millisecond))
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Slow"},
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			<-ctx.Done()
+
+			return nil, status.FromContextError(ctx.Err()).Err()
+		})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	entries := rec.Entries().Message("deadline exceeded")
+	require.Equal(t, 1, entries.Len())
+	require.Equal(t, 1, entries.HasField("elapsed").Len())
+
+	require.NoError(t, callUnary(interceptor, context.Background(), "/svc/Other"))
+	require.Equal(t, 1, rec.Entries().Message("deadline exceeded").Len())
+}
+
+func TestDeadlineInterceptors(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+
+	conn := startHealthServer(t, WithDeadlineInterceptors(rec.Inject(context.Background()),
+		WithMaxTimeout("/grpc.health.v1.Health/Watch", 50*time.Millisecond)), nil)
+	client := healthpb.NewHealthClient(conn)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	// the health server ends the stream with codes.Canceled on its context
+	// done
+	_, err = stream.Recv()
+	require.Equal(t, codes.Canceled, status.Code(err))
+
+	require.Eventually(t, func() bool {
+		return rec.Entries().Message("deadline exceeded").Field("method", "/grpc.health.v1.Health/Watch").Len() == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDeadlineStreamDefault(t *testing.T) {
+	interceptor := DeadlineStreamServerInterceptor(context.Background(),
+		WithDefaultTimeout(10*time.Millisecond),
+		WithMaxTimeout("/svc/Bounded", time.Second))
+
+	deadline := func(method string) bool {
+		var ok bool
+
+		err := interceptor(nil, &contextServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: method},
+			func(_ interface{}, ss grpc.ServerStream) error {
+				_, ok = ss.Context().Deadline()
+
+				return nil
+			})
+		require.NoError(t, err)
+
+		return ok
+	}
+
+	require.False(t, deadline("/svc/Subscribe"), "the default timeout is for the unary calls")
+	require.True(t, deadline("/svc/Bounded"), "the streams opt in with the max timeout")
+}
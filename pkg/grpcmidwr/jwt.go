@@ -0,0 +1,207 @@
+package grpcmidwr
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"slices"
+	"strings"
+	"time"
+)
+
+var jwtAlgs = map[string]func() hash.Hash{
+	"HS256": sha256.New,
+	"HS384": sha512.New384,
+	"HS512": sha512.New,
+}
+
+var (
+	// ErrTokenExpired is returned by HMACJWT for a token past its exp claim.
+	ErrTokenExpired = errors.New("token expired")
+	// ErrTokenNotValidYet is returned by HMACJWT for a token before its nbf
+	// claim.
+	ErrTokenNotValidYet = errors.New("token not valid yet")
+)
+
+// HMACJWT verifies bearer tokens signed with HS256, HS384 or HS512. The exp
+// claim is required, the nbf claim is checked when present. The sub claim is
+// the principal subject, all claims are kept in Principal.Claims.
+//
+// The expired and not yet valid tokens are rejected with ErrTokenExpired and
+// ErrTokenNotValidYet, any other failure with ErrInvalidCredentials.
+type HMACJWT struct {
+	secret   []byte
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+type JWTOption func(*HMACJWT)
+
+// WithIssuer requires the iss claim.
+func WithIssuer(iss string) JWTOption {
+	return func(v *HMACJWT) {
+		v.issuer = iss
+	}
+}
+
+// WithAudience requires the aud claim to contain aud.
+func WithAudience(aud string) JWTOption {
+	return func(v *HMACJWT) {
+		v.audience = aud
+	}
+}
+
+// WithLeeway allows the clock skew when checking exp and nbf.
+func WithLeeway(d time.Duration) JWTOption {
+	return func(v *HMACJWT) {
+		v.leeway = d
+	}
+}
+
+func NewHMACJWT(secret []byte, opts ...JWTOption) *HMACJWT {
+	v := &HMACJWT{
+		secret: secret,
+		now:    time.Now,
+	}
+
+	for _, o := range opts {
+		o(v)
+	}
+
+	return v
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+func (v *HMACJWT) Verify(_ context.Context, c Credentials) (*Principal, error) {
+	if c.Scheme != SchemeBearer {
+		return nil, ErrInvalidCredentials
+	}
+
+	claims, err := v.parse(c.Token)
+	if err != nil {
+		if errors.Is(err, ErrTokenExpired) || errors.Is(err, ErrTokenNotValidYet) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	sub, _ := claims["sub"].(string)
+
+	return &Principal{Subject: sub, Claims: claims}, nil
+}
+
+func (v *HMACJWT) parse(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var h jwtHeader
+
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("decode header error: %w", err)
+	}
+
+	newHash, ok := jwtAlgs[h.Alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported alg %q", h.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode signature error: %w", err)
+	}
+
+	mac := hmac.New(newHash, v.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, errors.New("signature mismatch")
+	}
+
+	var claims map[string]any
+
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("decode claims error: %w", err)
+	}
+
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (v *HMACJWT) validate(claims map[string]any) error {
+	now := v.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("missing exp claim")
+	}
+
+	if now.After(unixTime(exp).Add(v.leeway)) {
+		return ErrTokenExpired
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.leeway).Before(unixTime(nbf)) {
+		return ErrTokenNotValidYet
+	}
+
+	if v.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+
+	if v.audience != "" && !slices.Contains(audience(claims["aud"]), v.audience) {
+		return errors.New("unexpected audience")
+	}
+
+	return nil
+}
+
+// audience returns the aud claim, a string or an array of strings.
+func audience(aud any) []string {
+	switch a := aud.(type) {
+	case string:
+		return []string{a}
+	case []any:
+		res := make([]string, 0, len(a))
+
+		for _, e := range a {
+			if s, ok := e.(string); ok {
+				res = append(res, s)
+			}
+		}
+
+		return res
+	default:
+		return nil
+	}
+}
+
+func unixTime(f float64) time.Time {
+	return time.Unix(int64(f), 0)
+}
+
+func decodeSegment(seg string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, v)
+}
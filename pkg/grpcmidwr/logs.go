@@ -3,6 +3,7 @@ package grpcmidwr
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/0wnperception/go-helpers/pkg/log"
 	"google.golang.org/grpc"
@@ -10,55 +11,81 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-func WithLogUnaryInterceptor(logCtx context.Context) grpc.ServerOption {
-	return grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		var consumer string
+// WithLogUnaryInterceptor chains the logging interceptor, the interceptors
+// chained before it, e.g. WithTraceUnaryInterceptor, wrap it.
+func WithLogUnaryInterceptor(logCtx context.Context, opts ...LogOption) grpc.ServerOption {
+	cfg := newLogConfig(opts)
 
-		netInfo, ok := peer.FromContext(ctx)
-		if ok {
-			consumer = netInfo.Addr.String()
+	return grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		mc := cfg.method(info.FullMethod)
+		if mc.Skip {
+			return handler(ctx, req)
 		}
 
 		l := log.FromContext(logCtx)
 
 		logCtx := l.Inject(ctx)
 
-		logCtx = log.WithFields(logCtx,
-			log.String("consumer", consumer),
+		fields := []log.Field{
+			log.String("consumer", consumerAddr(ctx)),
 			log.String("method", info.FullMethod),
-			log.Reflect("request", req),
-		)
+		}
+
+		if !mc.NoPayloads {
+			fields = append(fields, mc.payload("request", req))
+		}
+
+		logCtx = log.WithFields(logCtx, fields...)
+
+		start := time.Now()
 
 		resp, err := handler(logCtx, req)
 
 		st, ok := status.FromError(err)
+		if err == nil && !mc.sampled() {
+			return resp, err
+		}
+
+		result := []log.Field{
+			log.Duration("latency", time.Since(start)),
+			log.String("code", st.Code().String()),
+		}
+
+		if err != nil {
+			result = append(result, log.Error(err))
+		} else if !mc.NoPayloads {
+			result = append(result, mc.payload("response", resp))
+		}
+
+		lvl := cfg.level(st.Code())
+
 		if ok {
-			log.Debug(logCtx, fmt.Sprintf("status '%d'", st.Code()), log.Reflect("response", resp))
+			logAt(logCtx, lvl, fmt.Sprintf("status '%d'", st.Code()), result...)
 		} else {
-			log.Debug(logCtx, "status 'uncknown'", log.Reflect("response", resp))
+			logAt(logCtx, lvl, "status 'uncknown'", result...)
 		}
 
 		return resp, err
 	})
 }
 
-func WithLogStreamInterceptor(logCtx context.Context) grpc.ServerOption {
-	return grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		streamCtx := ss.Context()
-
-		var consumer string
+func WithLogStreamInterceptor(logCtx context.Context, opts ...LogOption) grpc.ServerOption {
+	cfg := newLogConfig(opts)
 
-		netInfo, ok := peer.FromContext(streamCtx)
-		if ok {
-			consumer = netInfo.Addr.String()
+	return grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		mc := cfg.method(info.FullMethod)
+		if mc.Skip {
+			return handler(srv, ss)
 		}
 
+		streamCtx := ss.Context()
+
 		l := log.FromContext(logCtx)
 
 		streamLogCtx := l.Inject(streamCtx)
 
 		streamLogCtx = log.WithFields(streamLogCtx,
-			log.String("consumer", consumer),
+			log.String("consumer", consumerAddr(streamCtx)),
 			log.String("method", info.FullMethod),
 		)
 
@@ -66,19 +93,42 @@ func WithLogStreamInterceptor(logCtx context.Context) grpc.ServerOption {
 
 		newStream := WrapServerStream(ss)
 		newStream.LogContext = streamLogCtx
+		newStream.config = &mc
+
+		start := time.Now()
 
 		err := handler(srv, newStream)
 
-		log.Debug(streamLogCtx, "stream connection is closed")
+		st, _ := status.FromError(err)
+
+		result := []log.Field{
+			log.Duration("latency", time.Since(start)),
+			log.String("code", st.Code().String()),
+		}
+
+		if err != nil {
+			result = append(result, log.Error(err))
+		}
+
+		logAt(streamLogCtx, cfg.level(st.Code()), "stream connection is closed", result...)
 
 		return err
 	})
 }
 
-func WithLogInterceptors(logCtx context.Context) []grpc.ServerOption {
+// consumerAddr returns the address of the peer of the call, empty when unknown.
+func consumerAddr(ctx context.Context) string {
+	if netInfo, ok := peer.FromContext(ctx); ok {
+		return netInfo.Addr.String()
+	}
+
+	return ""
+}
+
+func WithLogInterceptors(logCtx context.Context, opts ...LogOption) []grpc.ServerOption {
 	return []grpc.ServerOption{
-		WithLogUnaryInterceptor(logCtx),
-		WithLogStreamInterceptor(logCtx),
+		WithLogUnaryInterceptor(logCtx, opts...),
+		WithLogStreamInterceptor(logCtx, opts...),
 	}
 }
 
@@ -87,6 +137,8 @@ type StreamLogWrapper struct {
 	grpc.ServerStream
 	// WrappedContext is the wrapper's own Context. You can assign it.
 	LogContext context.Context
+
+	config *MethodLogConfig
 }
 
 // Context returns the wrapper's WrappedContext, overwriting the nested grpc.ServerStream.Context()
@@ -95,9 +147,19 @@ func (w *StreamLogWrapper) Context() context.Context {
 }
 
 func (w *StreamLogWrapper) SendMsg(m interface{}) error {
-	ctx := log.WithFields(w.LogContext, log.Reflect("update", m))
+	var mc MethodLogConfig
+	if w.config != nil {
+		mc = *w.config
+	}
+
+	ctx := w.LogContext
+	if !mc.NoPayloads {
+		ctx = log.WithFields(ctx, mc.payload("update", m))
+	}
 
-	log.Debug(ctx, "send to stream")
+	if mc.sampled() {
+		log.Debug(ctx, "send to stream")
+	}
 
 	if err := w.ServerStream.SendMsg(m); err != nil {
 		log.Err(ctx, "sending update error", log.Error(err))
@@ -0,0 +1,165 @@
+package grpcmidwr
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+	"github.com/0wnperception/go-helpers/pkg/log/logtest"
+)
+
+func TestLogInterceptors(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+
+	conn := startHealthServer(t, WithLogInterceptors(rec.Inject(context.Background())), nil)
+	client := healthpb.NewHealthClient(conn)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Error(t, err)
+
+	ok := rec.Entries().Message("status '0'")
+	require.Equal(t, 1, ok.Level(zapcore.DebugLevel).Field("code", "OK").HasField("latency").HasField("response").Len())
+	require.Equal(t, 1, ok.Field("method", "/grpc.health.v1.Health/Check").HasField("request").Len())
+
+	notFound := rec.Entries().Message("status '5'")
+	require.Equal(t, 1, notFound.Level(zapcore.WarnLevel).Field("code", "NotFound").HasField("error").Len())
+}
+
+func TestLogInterceptorsSkip(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+
+	conn := startHealthServer(t, WithLogInterceptors(rec.Inject(context.Background()),
+		WithLogSkip("/grpc.health.v1.Health/"),
+	), nil)
+	client := healthpb.NewHealthClient(conn)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+	require.Zero(t, rec.Len())
+}
+
+func TestLogInterceptorsLevels(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+
+	conn := startHealthServer(t, WithLogInterceptors(rec.Inject(context.Background()),
+		WithStatusLevel(codes.NotFound, zapcore.ErrorLevel),
+		WithDefaultLogConfig(MethodLogConfig{NoPayloads: true, SampleRate: 0.0001}),
+	), nil)
+	client := healthpb.NewHealthClient(conn)
+
+	for range 10 {
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+		require.Error(t, err)
+	}
+
+	// failed calls are not sampled
+	entries := rec.Entries().Message("status '5'")
+	require.Equal(t, 10, entries.Level(zapcore.ErrorLevel).Len())
+	require.Zero(t, entries.HasField("request").Len())
+}
+
+func TestLogInterceptorsRedaction(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+
+	r, err := log.NewRedactor(log.RedactionConfig{Keys: []string{"service"}})
+	require.NoError(t, err)
+
+	logCtx := rec.Log().WithRedactor(r).Inject(context.Background())
+
+	conn := startHealthServer(t, WithLogInterceptors(logCtx), nil)
+	client := healthpb.NewHealthClient(conn)
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	entries := rec.Entries().Message("status '0'")
+	require.Equal(t, 1, entries.Len())
+
+	req, ok := entries[0].Field("request")
+	require.True(t, ok)
+
+	raw, err := json.Marshal(req)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"service":"***"}`, string(raw))
+}
+
+func TestPayloadTaggedRequest(t *testing.T) {
+	type loginRequest struct {
+		User     string `json:"user"`
+		Password string `json:"password" log:"secret"`
+		Token    string `json:"token"`
+	}
+
+	req := loginRequest{User: "agent", Password: "pass", Token: "tok"}
+
+	r, err := log.NewRedactor(log.RedactionConfig{Keys: []string{"token"}})
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		log  func(*logtest.Recorder) *log.Log
+		want string
+	}{
+		"tags only": {
+			log:  (*logtest.Recorder).Log,
+			want: `{"user":"agent","password":"***","token":"tok"}`,
+		},
+		"redactor": {
+			log:  func(rec *logtest.Recorder) *log.Log { return rec.Log().WithRedactor(r) },
+			want: `{"user":"agent","password":"***","token":"***"}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := logtest.New(zap.DebugLevel)
+
+			ctx := tc.log(rec).Inject(context.Background())
+			log.Info(ctx, "call", MethodLogConfig{}.payload("request", req))
+
+			f, ok := rec.Entries().Message("call")[0].Field("request")
+			require.True(t, ok)
+
+			raw, err := json.Marshal(f)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.want, string(raw))
+		})
+	}
+}
+
+func TestPayloadValue(t *testing.T) {
+	msg := &healthpb.HealthCheckRequest{Service: "app"}
+
+	raw, err := json.Marshal(payloadValue{msg: msg, limit: DefaultPayloadLimit})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"service":"app"}`, string(raw))
+
+	raw, err = json.Marshal(payloadValue{msg: map[string]string{"service": "application"}, limit: 10})
+	require.NoError(t, err)
+
+	var s string
+	require.NoError(t, json.Unmarshal(raw, &s))
+	require.Equal(t, `{"service"...(15 bytes truncated)`, s)
+
+	raw, err = json.Marshal(payloadValue{msg: map[string]string{"service": "application"}, limit: -1})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"service":"application"}`, string(raw))
+
+	r, err := log.NewRedactor(log.RedactionConfig{Keys: []string{"service"}})
+	require.NoError(t, err)
+
+	// the payload is redacted before it is truncated
+	redacted := payloadValue{msg: msg, limit: 14}.Redacted("request", r)
+
+	raw, err = json.Marshal(redacted)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, &s))
+	require.Equal(t, `{"service":"**...(3 bytes truncated)`, s)
+}
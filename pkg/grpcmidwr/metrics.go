@@ -0,0 +1,178 @@
+package grpcmidwr
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/0wnperception/go-helpers/pkg/monitor/metrics"
+)
+
+const (
+	typeUnary        = "unary"
+	typeClientStream = "client_stream"
+	typeServerStream = "server_stream"
+	typeBidiStream   = "bidi_stream"
+)
+
+// Metrics holds the server metrics maintained by the metrics interceptors:
+//
+//	grpc_server_started_total{grpc_type,grpc_service,grpc_method}
+//	grpc_server_handled_total{grpc_type,grpc_service,grpc_method,grpc_code}
+//	grpc_server_handling_seconds{grpc_type,grpc_service,grpc_method}
+//	grpc_server_in_flight{grpc_type,grpc_service,grpc_method}
+//	grpc_server_msg_received_total{grpc_type,grpc_service,grpc_method}
+//	grpc_server_msg_sent_total{grpc_type,grpc_service,grpc_method}
+//	grpc_server_panics_recovered_total
+type Metrics struct {
+	started  *metrics.CounterVec
+	handled  *metrics.CounterVec
+	latency  *metrics.HistogramVec
+	inFlight *metrics.GaugeVec
+	received *metrics.CounterVec
+	sent     *metrics.CounterVec
+}
+
+// NewMetrics registers the server metrics in reg, the latency buckets are
+// metrics.DefBuckets when empty.
+func NewMetrics(reg *metrics.Registry, buckets ...float64) (*Metrics, error) {
+	labels := []string{"grpc_type", "grpc_service", "grpc_method"}
+
+	m := &Metrics{
+		started: metrics.NewCounterVec("grpc_server_started_total",
+			"Total number of RPCs started on the server.", labels...),
+		handled: metrics.NewCounterVec("grpc_server_handled_total",
+			"Total number of RPCs completed on the server, regardless of success or failure.",
+			append(labels, "grpc_code")...),
+		latency: metrics.NewHistogramVec("grpc_server_handling_seconds",
+			"Histogram of response latency (seconds) of gRPC that had been application-level handled by the server.",
+			buckets, labels...),
+		inFlight: metrics.NewGaugeVec("grpc_server_in_flight",
+			"Number of RPCs being handled on the server.", labels...),
+		received: metrics.NewCounterVec("grpc_server_msg_received_total",
+			"Total number of RPC stream messages received on the server.", labels...),
+		sent: metrics.NewCounterVec("grpc_server_msg_sent_total",
+			"Total number of gRPC stream messages sent by the server.", labels...),
+	}
+
+	panicsRecovered := metrics.NewCounterFunc("grpc_server_panics_recovered_total",
+		"Total number of panics recovered by the recovery interceptors.", func() float64 {
+			return float64(PanicCount())
+		})
+
+	if err := reg.Register(m.started, m.handled, m.latency, m.inFlight, m.received, m.sent, panicsRecovered); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// splitMethod splits "/package.Service/Method" into the service and the method.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", "unknown"
+	}
+
+	return service, method
+}
+
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return typeBidiStream
+	case info.IsClientStream:
+		return typeClientStream
+	case info.IsServerStream:
+		return typeServerStream
+	default:
+		return typeUnary
+	}
+}
+
+// call starts counting a call, the returned function finishes it.
+func (m *Metrics) call(typ, fullMethod string) func(err error) {
+	service, method := splitMethod(fullMethod)
+
+	m.started.With(typ, service, method).Inc()
+
+	inFlight := m.inFlight.With(typ, service, method)
+	inFlight.Inc()
+
+	start := time.Now()
+
+	return func(err error) {
+		inFlight.Dec()
+
+		m.latency.With(typ, service, method).Observe(time.Since(start).Seconds())
+		m.handled.With(typ, service, method, status.Code(err).String()).Inc()
+	}
+}
+
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		done := m.call(typeUnary, info.FullMethod)
+
+		resp, err := handler(ctx, req)
+
+		done(err)
+
+		return resp, err
+	}
+}
+
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		typ := streamType(info)
+		service, method := splitMethod(info.FullMethod)
+
+		done := m.call(typ, info.FullMethod)
+
+		err := handler(srv, &metricsServerStream{
+			ServerStream: ss,
+			received:     m.received.With(typ, service, method),
+			sent:         m.sent.With(typ, service, method),
+		})
+
+		done(err)
+
+		return err
+	}
+}
+
+// WithMetricsInterceptors chains the metrics interceptors, serve the metrics
+// with the Handler of the registry passed to NewMetrics.
+func WithMetricsInterceptors(m *Metrics) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(m.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(m.StreamServerInterceptor()),
+	}
+}
+
+type metricsServerStream struct {
+	grpc.ServerStream
+
+	received *metrics.Counter
+	sent     *metrics.Counter
+}
+
+func (s *metricsServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Inc()
+	}
+
+	return err
+}
+
+func (s *metricsServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Inc()
+	}
+
+	return err
+}
@@ -0,0 +1,62 @@
+package grpcmidwr
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/0wnperception/go-helpers/pkg/monitor/metrics"
+)
+
+func TestMetricsInterceptors(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	m, err := NewMetrics(reg)
+	require.NoError(t, err)
+
+	_, err = NewMetrics(reg)
+	require.ErrorIs(t, err, metrics.ErrDuplicateMetric)
+
+	conn := startHealthServer(t, WithMetricsInterceptors(m), nil)
+	client := healthpb.NewHealthClient(conn)
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	watch := []string{typeServerStream, "grpc.health.v1.Health", "Watch"}
+	require.Equal(t, float64(1), m.inFlight.With(watch...).Value())
+
+	cancel()
+
+	require.Eventually(t, func() bool {
+		return m.inFlight.With(watch...).Value() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	var sb strings.Builder
+	require.NoError(t, reg.Write(&sb))
+
+	out := sb.String()
+	require.Contains(t, out, `grpc_server_started_total{grpc_type="unary",grpc_service="grpc.health.v1.Health",grpc_method="Check"} 2`)
+	require.Contains(t, out, `grpc_server_handled_total{grpc_type="unary",grpc_service="grpc.health.v1.Health",grpc_method="Check",grpc_code="OK"} 1`)
+	require.Contains(t, out, `grpc_server_handled_total{grpc_type="unary",grpc_service="grpc.health.v1.Health",grpc_method="Check",grpc_code="NotFound"} 1`)
+	require.Contains(t, out, `grpc_server_handling_seconds_count{grpc_type="unary",grpc_service="grpc.health.v1.Health",grpc_method="Check"} 2`)
+	require.Contains(t, out, `grpc_server_in_flight{grpc_type="unary",grpc_service="grpc.health.v1.Health",grpc_method="Check"} 0`)
+	require.Contains(t, out, `grpc_server_msg_sent_total{grpc_type="server_stream",grpc_service="grpc.health.v1.Health",grpc_method="Watch"} 1`)
+	require.Contains(t, out, `grpc_server_msg_received_total{grpc_type="server_stream",grpc_service="grpc.health.v1.Health",grpc_method="Watch"} 1`)
+	require.Contains(t, out, `# TYPE grpc_server_panics_recovered_total counter`)
+}
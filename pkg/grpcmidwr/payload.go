@@ -0,0 +1,201 @@
+package grpcmidwr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+// DefaultPayloadLimit is the default size of a logged payload in bytes.
+const DefaultPayloadLimit = 4096
+
+// MethodLogConfig controls the logging of the calls of a method.
+type MethodLogConfig struct {
+	// Skip disables the logging of the method, e.g. health checks.
+	Skip bool
+	// NoPayloads omits the request, response and stream messages.
+	NoPayloads bool
+	// PayloadLimit truncates the rendered payloads, DefaultPayloadLimit when
+	// zero, negative means no limit.
+	PayloadLimit int
+	// SampleRate is the share of successful calls logged, zero logs all of them.
+	// Failed calls are always logged.
+	SampleRate float64
+}
+
+type logConfig struct {
+	def     MethodLogConfig
+	methods map[string]MethodLogConfig
+	levels  map[codes.Code]zapcore.Level
+}
+
+type LogOption func(*logConfig)
+
+// WithMethodLogConfig sets the config of a method, e.g. "/pkg.Service/Method",
+// or of all methods of a service, e.g. "/pkg.Service/".
+func WithMethodLogConfig(method string, c MethodLogConfig) LogOption {
+	return func(cfg *logConfig) {
+		cfg.methods[method] = c
+	}
+}
+
+// WithDefaultLogConfig sets the config of the methods without their own.
+func WithDefaultLogConfig(c MethodLogConfig) LogOption {
+	return func(cfg *logConfig) {
+		cfg.def = c
+	}
+}
+
+// WithLogSkip disables the logging of the methods or services.
+func WithLogSkip(methods ...string) LogOption {
+	return func(cfg *logConfig) {
+		for _, m := range methods {
+			cfg.methods[m] = MethodLogConfig{Skip: true}
+		}
+	}
+}
+
+// WithStatusLevel sets the level of the call result with the code.
+func WithStatusLevel(code codes.Code, level zapcore.Level) LogOption {
+	return func(cfg *logConfig) {
+		cfg.levels[code] = level
+	}
+}
+
+func newLogConfig(opts []LogOption) *logConfig {
+	cfg := &logConfig{
+		methods: make(map[string]MethodLogConfig),
+		levels: map[codes.Code]zapcore.Level{
+			codes.OK:                 zapcore.DebugLevel,
+			codes.Canceled:           zapcore.WarnLevel,
+			codes.InvalidArgument:    zapcore.WarnLevel,
+			codes.NotFound:           zapcore.WarnLevel,
+			codes.AlreadyExists:      zapcore.WarnLevel,
+			codes.PermissionDenied:   zapcore.WarnLevel,
+			codes.Unauthenticated:    zapcore.WarnLevel,
+			codes.ResourceExhausted:  zapcore.WarnLevel,
+			codes.FailedPrecondition: zapcore.WarnLevel,
+			codes.Aborted:            zapcore.WarnLevel,
+			codes.OutOfRange:         zapcore.WarnLevel,
+		},
+	}
+
+	for _, o := range opts {
+		o(cfg)
+	}
+
+	return cfg
+}
+
+// method returns the config of the method, then of its service, then the
+// default one.
+func (c *logConfig) method(fullMethod string) MethodLogConfig {
+	if m, ok := c.methods[fullMethod]; ok {
+		return m
+	}
+
+	if i := strings.LastIndexByte(fullMethod, '/'); i > 0 {
+		if m, ok := c.methods[fullMethod[:i+1]]; ok {
+			return m
+		}
+	}
+
+	return c.def
+}
+
+// level returns the level of the call result, server errors by default.
+func (c *logConfig) level(code codes.Code) zapcore.Level {
+	if l, ok := c.levels[code]; ok {
+		return l
+	}
+
+	return zapcore.ErrorLevel
+}
+
+func (m MethodLogConfig) sampled() bool {
+	return m.SampleRate <= 0 || m.SampleRate >= 1 || rand.Float64() < m.SampleRate //nolint:gosec
+}
+
+// payload returns a field rendering the message when the entry is encoded.
+func (m MethodLogConfig) payload(key string, msg interface{}) log.Field {
+	limit := m.PayloadLimit
+	if limit == 0 {
+		limit = DefaultPayloadLimit
+	}
+
+	return log.Any(key, payloadValue{msg: msg, limit: limit})
+}
+
+// payloadValue renders the message with protojson when it is a proto message
+// and with encoding/json otherwise. A payload over the limit is truncated and
+// logged as a string.
+type payloadValue struct {
+	msg   interface{}
+	limit int
+}
+
+func (p payloadValue) render() ([]byte, error) {
+	if pm, ok := p.msg.(proto.Message); ok {
+		return protojson.Marshal(pm)
+	}
+
+	return json.Marshal(p.msg)
+}
+
+// Redacted passes the message through the redactor of the logger, so the
+// fields are masked before the payload is truncated. A proto message is
+// redacted as its protojson map, other messages as is, honouring their log
+// tags.
+func (p payloadValue) Redacted(key string, r *log.Redactor) any {
+	pm, ok := p.msg.(proto.Message)
+	if !ok {
+		return payloadValue{msg: r.Value(key, p.msg), limit: p.limit}
+	}
+
+	raw, err := protojson.Marshal(pm)
+	if err != nil {
+		return p
+	}
+
+	var tree any
+	if err = json.Unmarshal(raw, &tree); err != nil {
+		return p
+	}
+
+	return payloadValue{msg: r.Value(key, tree), limit: p.limit}
+}
+
+func (p payloadValue) MarshalJSON() ([]byte, error) {
+	raw, err := p.render()
+	if err != nil {
+		return json.Marshal(fmt.Sprintf("%+v", p.msg))
+	}
+
+	if p.limit > 0 && len(raw) > p.limit {
+		return json.Marshal(fmt.Sprintf("%s...(%d bytes truncated)", raw[:p.limit], len(raw)-p.limit))
+	}
+
+	return raw, nil
+}
+
+func logAt(ctx context.Context, lvl zapcore.Level, msg string, fields ...log.Field) {
+	switch lvl {
+	case zapcore.DebugLevel:
+		log.Debug(ctx, msg, fields...)
+	case zapcore.InfoLevel:
+		log.Info(ctx, msg, fields...)
+	case zapcore.WarnLevel:
+		log.Warn(ctx, msg, fields...)
+	default:
+		log.Err(ctx, msg, fields...)
+	}
+}
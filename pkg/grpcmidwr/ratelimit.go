@@ -0,0 +1,345 @@
+package grpcmidwr
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/0wnperception/go-helpers/pkg/concurrent"
+)
+
+// RetryAfterHeader carries the seconds to wait before retrying a call
+// rejected by the limiters, e.g. "0.250".
+const RetryAfterHeader = "retry-after"
+
+// peerSweepEvery is the period of removing the buckets of idle peers.
+const peerSweepEvery = time.Minute
+
+// minConcurrencyRetryAfter is the retry-after of the calls rejected by a
+// concurrency limiter which does not wait for a free slot.
+const minConcurrencyRetryAfter = 100 * time.Millisecond
+
+// RateLimit is a token bucket refilled with Rate tokens per second up to
+// Burst tokens, every call takes one token.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l RateLimit) enabled() bool {
+	return l.Rate > 0
+}
+
+type tokenBucket struct {
+	limit RateLimit
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(l RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{limit: l, tokens: float64(max(l.Burst, 1)), last: now}
+}
+
+// take returns zero when the token is taken, otherwise the time until the
+// next token.
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	burst := float64(max(b.limit.Burst, 1))
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed.Seconds()*b.limit.Rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+}
+
+// refund returns the token taken by the call rejected by another bucket.
+func (b *tokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(float64(max(b.limit.Burst, 1)), b.tokens+1)
+}
+
+// idle reports whether the bucket is full at now, so it can be recreated.
+func (b *tokenBucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate >= float64(max(b.limit.Burst, 1))
+}
+
+type RateLimitOption func(*RateLimiter)
+
+// WithMethodRateLimit limits a method, e.g. "/pkg.Service/Method", or every
+// method of a service, e.g. "/pkg.Service/", across all peers.
+func WithMethodRateLimit(method string, l RateLimit) RateLimitOption {
+	return func(r *RateLimiter) {
+		r.methodLimits[method] = l
+	}
+}
+
+// WithDefaultRateLimit limits every method without its own limit.
+func WithDefaultRateLimit(l RateLimit) RateLimitOption {
+	return func(r *RateLimiter) {
+		r.defaultLimit = l
+	}
+}
+
+// WithPeerRateLimit limits the calls of every peer host across all methods.
+func WithPeerRateLimit(l RateLimit) RateLimitOption {
+	return func(r *RateLimiter) {
+		r.peerLimit = l
+	}
+}
+
+// RateLimiter rejects the calls over the limits with codes.ResourceExhausted
+// and the retry-after header.
+type RateLimiter struct {
+	methodLimits map[string]RateLimit
+	defaultLimit RateLimit
+	peerLimit    RateLimit
+
+	mu        sync.Mutex
+	methods   map[string]*tokenBucket
+	peers     map[string]*tokenBucket
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+func NewRateLimiter(opts ...RateLimitOption) *RateLimiter {
+	r := &RateLimiter{
+		methodLimits: make(map[string]RateLimit),
+		methods:      make(map[string]*tokenBucket),
+		peers:        make(map[string]*tokenBucket),
+		now:          time.Now,
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	r.lastSweep = r.now()
+
+	return r
+}
+
+// methodLimit returns the key and the limit of the method, then of its
+// service, then the default one.
+func (r *RateLimiter) methodLimit(fullMethod string) (string, RateLimit) {
+	if l, ok := r.methodLimits[fullMethod]; ok {
+		return fullMethod, l
+	}
+
+	if i := strings.LastIndexByte(fullMethod, '/'); i > 0 {
+		if l, ok := r.methodLimits[fullMethod[:i+1]]; ok {
+			return fullMethod[:i+1], l
+		}
+	}
+
+	return fullMethod, r.defaultLimit
+}
+
+func (r *RateLimiter) bucket(buckets map[string]*tokenBucket, key string, l RateLimit, now time.Time) *tokenBucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = newTokenBucket(l, now)
+		buckets[key] = b
+	}
+
+	return b
+}
+
+// sweep removes the buckets of the peers which have been idle long enough to
+// refill, they are recreated full on the next call.
+func (r *RateLimiter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < peerSweepEvery {
+		return
+	}
+
+	r.lastSweep = now
+
+	for k, b := range r.peers {
+		if b.idle(now) {
+			delete(r.peers, k)
+		}
+	}
+}
+
+// allow returns zero when the call is allowed, otherwise the time to wait.
+func (r *RateLimiter) allow(ctx context.Context, fullMethod string) time.Duration {
+	now := r.now()
+
+	var buckets []*tokenBucket
+
+	r.mu.Lock()
+
+	if key, l := r.methodLimit(fullMethod); l.enabled() {
+		buckets = append(buckets, r.bucket(r.methods, key, l, now))
+	}
+
+	if r.peerLimit.enabled() {
+		r.sweep(now)
+		buckets = append(buckets, r.bucket(r.peers, peerHost(ctx), r.peerLimit, now))
+	}
+
+	r.mu.Unlock()
+
+	for i, b := range buckets {
+		if wait := b.take(now); wait > 0 {
+			for _, taken := range buckets[:i] {
+				taken.refund()
+			}
+
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// peerHost returns the host of the peer address the log interceptor logs as
+// the consumer, so all connections of a client share the limit.
+func peerHost(ctx context.Context) string {
+	addr := consumerAddr(ctx)
+
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+
+	return addr
+}
+
+func resourceExhausted(msg string, retryAfter time.Duration) error {
+	st := status.New(codes.ResourceExhausted, msg)
+
+	if retryAfter > 0 {
+		if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+			st = detailed
+		}
+	}
+
+	return st.Err()
+}
+
+func retryAfterMD(d time.Duration) metadata.MD {
+	return metadata.Pairs(RetryAfterHeader, strconv.FormatFloat(d.Seconds(), 'f', 3, 64))
+}
+
+func (r *RateLimiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if wait := r.allow(ctx, info.FullMethod); wait > 0 {
+			_ = grpc.SetHeader(ctx, retryAfterMD(wait))
+
+			return nil, resourceExhausted("rate limit exceeded", wait)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func (r *RateLimiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if wait := r.allow(ss.Context(), info.FullMethod); wait > 0 {
+			_ = ss.SetHeader(retryAfterMD(wait))
+
+			return resourceExhausted("rate limit exceeded", wait)
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func WithRateLimitInterceptors(r *RateLimiter) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(r.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(r.StreamServerInterceptor()),
+	}
+}
+
+// ConcurrencyLimiter limits the number of calls in flight. A call waits for a
+// free slot up to the wait timeout, zero rejects it at once. The rejected calls
+// get codes.ResourceExhausted and the retry-after header of the wait timeout,
+// at least 100ms.
+type ConcurrencyLimiter struct {
+	c    *concurrent.Concurrent
+	wait time.Duration
+}
+
+func NewConcurrencyLimiter(c *concurrent.Concurrent, wait time.Duration) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{c: c, wait: wait}
+}
+
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) bool {
+	if l.wait <= 0 {
+		return l.c.TryBorrow()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.wait)
+	defer cancel()
+
+	return l.c.Borrow(ctx)
+}
+
+// retryAfter returns the time the rejected call should wait before retrying.
+func (l *ConcurrencyLimiter) retryAfter() time.Duration {
+	return max(l.wait, minConcurrencyRetryAfter)
+}
+
+func (l *ConcurrencyLimiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !l.acquire(ctx) {
+			wait := l.retryAfter()
+			_ = grpc.SetHeader(ctx, retryAfterMD(wait))
+
+			return nil, resourceExhausted("too many requests in flight", wait)
+		}
+		defer l.c.SettleUp()
+
+		return handler(ctx, req)
+	}
+}
+
+func (l *ConcurrencyLimiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !l.acquire(ss.Context()) {
+			wait := l.retryAfter()
+			_ = ss.SetHeader(retryAfterMD(wait))
+
+			return resourceExhausted("too many requests in flight", wait)
+		}
+		defer l.c.SettleUp()
+
+		return handler(srv, ss)
+	}
+}
+
+func WithConcurrencyLimitInterceptors(l *ConcurrencyLimiter) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(l.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(l.StreamServerInterceptor()),
+	}
+}
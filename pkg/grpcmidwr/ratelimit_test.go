@@ -0,0 +1,235 @@
+package grpcmidwr
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/0wnperception/go-helpers/pkg/concurrent"
+)
+
+func peerContext(addr string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(addr), Port: 40000}})
+}
+
+func callUnary(interceptor grpc.UnaryServerInterceptor, ctx context.Context, method string) error {
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+		func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+
+	return err
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	r := NewRateLimiter(
+		WithMethodRateLimit("/svc/Slow", RateLimit{Rate: 1, Burst: 2}),
+		WithMethodRateLimit("/other/", RateLimit{Rate: 10, Burst: 1}),
+		WithPeerRateLimit(RateLimit{Rate: 4, Burst: 3}),
+	)
+	r.now = func() time.Time { return now }
+	r.lastSweep = now
+
+	interceptor := r.UnaryServerInterceptor()
+	a, b := peerContext("10.0.0.1"), peerContext("10.0.0.2")
+
+	require.NoError(t, callUnary(interceptor, a, "/svc/Slow"))
+	require.NoError(t, callUnary(interceptor, b, "/svc/Slow"))
+
+	err := callUnary(interceptor, a, "/svc/Slow")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err), "method bucket is shared by peers")
+
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	require.Equal(t, time.Second, details[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+
+	require.NoError(t, callUnary(interceptor, a, "/other/Call"))
+	require.Equal(t, codes.ResourceExhausted, status.Code(callUnary(interceptor, b, "/other/Call")))
+
+	now = now.Add(100 * time.Millisecond)
+
+	require.NoError(t, callUnary(interceptor, a, "/free/Call"))
+
+	err = callUnary(interceptor, a, "/free/Call")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err), "peer bucket is exhausted")
+	require.Equal(t, 150*time.Millisecond, status.Convert(err).Details()[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+
+	require.NoError(t, callUnary(interceptor, b, "/free/Call"))
+
+	now = now.Add(2 * time.Minute)
+
+	require.NoError(t, callUnary(interceptor, b, "/free/Call"))
+	require.Len(t, r.peers, 1, "idle peer buckets are swept")
+}
+
+func TestRateLimitInterceptors(t *testing.T) {
+	conn := startHealthServer(t, WithRateLimitInterceptors(NewRateLimiter(
+		WithDefaultRateLimit(RateLimit{Rate: 0.5, Burst: 1}),
+	)), nil)
+	client := healthpb.NewHealthClient(conn)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	var header metadata.MD
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "app"}, grpc.Header(&header))
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Len(t, header.Get(RetryAfterHeader), 1)
+	require.NotEqual(t, "0.000", header.Get(RetryAfterHeader)[0])
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.NoError(t, err, "stream methods have own buckets")
+
+	stream, err = client.Watch(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	header, err = stream.Header()
+	require.NoError(t, err)
+	require.Len(t, header.Get(RetryAfterHeader), 1)
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	c := concurrent.NewConcurrent(concurrent.ConcurrentConfig{SimCapacity: 1}, 10)
+
+	fast := NewConcurrencyLimiter(c, 0).UnaryServerInterceptor()
+	waiting := NewConcurrencyLimiter(c, time.Second).UnaryServerInterceptor()
+
+	started, release := make(chan struct{}), make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		_, _ = fast(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Call"},
+			func(context.Context, interface{}) (interface{}, error) {
+				close(started)
+				<-release
+
+				return nil, nil
+			})
+	}()
+
+	<-started
+
+	err := callUnary(fast, context.Background(), "/svc/Call")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, minConcurrencyRetryAfter, status.Convert(err).Details()[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = callUnary(waiting, ctx, "/svc/Call")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err), "wait is bounded by ctx")
+	require.Equal(t, time.Second, status.Convert(err).Details()[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+
+	done := make(chan error, 1)
+
+	go func() { done <- callUnary(waiting, context.Background(), "/svc/Call") }()
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	require.NoError(t, <-done)
+	wg.Wait()
+
+	require.True(t, c.IsAvailable())
+}
+
+func TestConcurrencyLimitInterceptors(t *testing.T) {
+	c := concurrent.NewConcurrent(concurrent.ConcurrentConfig{SimCapacity: 1}, 10)
+
+	conn := startHealthServer(t, WithConcurrencyLimitInterceptors(NewConcurrencyLimiter(c, 0)), nil)
+	client := healthpb.NewHealthClient(conn)
+
+	// the only slot is taken
+	require.True(t, c.TryBorrow())
+	defer c.SettleUp()
+
+	var header metadata.MD
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "app"}, grpc.Header(&header))
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, []string{"0.100"}, header.Get(RetryAfterHeader))
+	require.Equal(t, minConcurrencyRetryAfter, status.Convert(err).Details()[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	header, err = stream.Header()
+	require.NoError(t, err)
+	require.Equal(t, []string{"0.100"}, header.Get(RetryAfterHeader))
+}
+
+func TestConcurrencyLimiterParallel(t *testing.T) {
+	const capacity, callers = 3, 50
+
+	for _, wait := range []time.Duration{0, time.Second} {
+		c := concurrent.NewConcurrent(concurrent.ConcurrentConfig{SimCapacity: capacity}, callers)
+		interceptor := NewConcurrencyLimiter(c, wait).UnaryServerInterceptor()
+
+		var inFlight, maxInFlight, rejected atomic.Int32
+
+		var wg sync.WaitGroup
+
+		for range callers {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Call"},
+					func(context.Context, interface{}) (interface{}, error) {
+						n := inFlight.Add(1)
+						for {
+							m := maxInFlight.Load()
+							if n <= m || maxInFlight.CompareAndSwap(m, n) {
+								break
+							}
+						}
+
+						time.Sleep(time.Millisecond)
+						inFlight.Add(-1)
+
+						return nil, nil
+					})
+				if err != nil {
+					rejected.Add(1)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		require.LessOrEqual(t, maxInFlight.Load(), int32(capacity))
+		require.True(t, c.IsAvailable())
+
+		if wait > 0 {
+			require.Zero(t, rejected.Load(), "callers wait for a slot")
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package grpcmidwr
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+// errInternal is returned to the client instead of the panic value, which may
+// carry internal details.
+var errInternal = status.Error(codes.Internal, "internal error")
+
+var panics atomic.Uint64
+
+// PanicCount returns the number of panics recovered by the interceptors.
+func PanicCount() uint64 {
+	return panics.Load()
+}
+
+// RecoveryHandler converts a recovered panic into the error returned to the
+// client, a nil error is replaced with codes.Internal.
+type RecoveryHandler func(ctx context.Context, p any) error
+
+type recoveryConfig struct {
+	handler RecoveryHandler
+}
+
+type RecoveryOption func(*recoveryConfig)
+
+// WithRecoveryHandler replaces the default handler returning codes.Internal.
+func WithRecoveryHandler(h RecoveryHandler) RecoveryOption {
+	return func(c *recoveryConfig) {
+		c.handler = h
+	}
+}
+
+func newRecoveryConfig(opts []RecoveryOption) *recoveryConfig {
+	c := &recoveryConfig{
+		handler: func(context.Context, any) error {
+			return errInternal
+		},
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	return c
+}
+
+func (c *recoveryConfig) recover(logCtx, ctx context.Context, method string, p any) error {
+	panics.Add(1)
+
+	// the logger of the request is set when the log interceptor is chained
+	// before the recovery one
+	errCtx := ctx
+	if log.FromContext(ctx) == log.Nop() {
+		errCtx = log.FromContext(logCtx).Inject(ctx)
+	}
+
+	log.Err(errCtx, "panic recovered",
+		log.String("method", method),
+		log.String("panic", fmt.Sprint(p)),
+		log.Stack("stack"),
+	)
+
+	if err := c.handler(ctx, p); err != nil {
+		return err
+	}
+
+	return errInternal
+}
+
+// RecoveryUnaryServerInterceptor converts the handler panics into errors.
+func RecoveryUnaryServerInterceptor(logCtx context.Context, opts ...RecoveryOption) grpc.UnaryServerInterceptor {
+	cfg := newRecoveryConfig(opts)
+
+	//nolint:nonamedreturns
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				resp, err = nil, cfg.recover(logCtx, ctx, info.FullMethod, p)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamServerInterceptor converts the stream handler panics into errors.
+func RecoveryStreamServerInterceptor(logCtx context.Context, opts ...RecoveryOption) grpc.StreamServerInterceptor {
+	cfg := newRecoveryConfig(opts)
+
+	//nolint:nonamedreturns
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = cfg.recover(logCtx, ss.Context(), info.FullMethod, p)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// WithRecoveryUnaryInterceptor chains RecoveryUnaryServerInterceptor. Put it
+// after WithLogInterceptors, so the panic is logged with the request fields.
+func WithRecoveryUnaryInterceptor(logCtx context.Context, opts ...RecoveryOption) grpc.ServerOption {
+	return grpc.ChainUnaryInterceptor(RecoveryUnaryServerInterceptor(logCtx, opts...))
+}
+
+// WithRecoveryStreamInterceptor chains RecoveryStreamServerInterceptor.
+func WithRecoveryStreamInterceptor(logCtx context.Context, opts ...RecoveryOption) grpc.ServerOption {
+	return grpc.ChainStreamInterceptor(RecoveryStreamServerInterceptor(logCtx, opts...))
+}
+
+func WithRecoveryInterceptors(logCtx context.Context, opts ...RecoveryOption) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		WithRecoveryUnaryInterceptor(logCtx, opts...),
+		WithRecoveryStreamInterceptor(logCtx, opts...),
+	}
+}
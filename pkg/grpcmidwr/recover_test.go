@@ -0,0 +1,83 @@
+package grpcmidwr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/0wnperception/go-helpers/pkg/log/logtest"
+)
+
+func TestRecoveryUnary(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+	before := PanicCount()
+
+	interceptor := RecoveryUnaryServerInterceptor(rec.Inject(context.Background()))
+
+	resp, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/svc/Call"},
+		func(context.Context, interface{}) (interface{}, error) {
+			panic("secret details")
+		})
+
+	require.Nil(t, resp)
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.NotContains(t, err.Error(), "secret")
+	require.Equal(t, before+1, PanicCount())
+
+	entries := rec.Entries().Message("panic recovered")
+	require.Equal(t, 1, entries.Len())
+	require.Equal(t, 1, entries.Field("method", "/svc/Call").Field("panic", "secret details").HasField("stack").Len())
+}
+
+func TestRecoveryUnaryNoPanic(t *testing.T) {
+	interceptor := RecoveryUnaryServerInterceptor(context.Background())
+
+	resp, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/svc/Call"},
+		func(_ context.Context, req interface{}) (interface{}, error) {
+			return req, nil
+		})
+
+	require.NoError(t, err)
+	require.Equal(t, "req", resp)
+}
+
+func TestRecoveryStreamHandler(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+
+	// the logger of the request context is preferred
+	ss := &contextServerStream{ctx: rec.Inject(context.Background())}
+
+	interceptor := RecoveryStreamServerInterceptor(context.Background(),
+		WithRecoveryHandler(func(_ context.Context, p any) error {
+			return status.Errorf(codes.Unavailable, "recovered %v", p)
+		}))
+
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/svc/Stream"},
+		func(interface{}, grpc.ServerStream) error {
+			panic(42)
+		})
+
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, "recovered 42", status.Convert(err).Message())
+	require.Equal(t, 1, rec.Entries().Message("panic recovered").Field("panic", "42").Len())
+}
+
+func TestRecoveryHandlerNilError(t *testing.T) {
+	interceptor := RecoveryUnaryServerInterceptor(context.Background(),
+		WithRecoveryHandler(func(context.Context, any) error {
+			return nil
+		}))
+
+	resp, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/svc/Call"},
+		func(context.Context, interface{}) (interface{}, error) {
+			panic("boom")
+		})
+
+	require.Nil(t, resp)
+	require.Equal(t, codes.Internal, status.Code(err), "the panic is never reported as a success")
+}
@@ -0,0 +1,136 @@
+package grpcmidwr
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+	"github.com/0wnperception/go-helpers/pkg/types"
+)
+
+const (
+	RequestIDHeader = "x-request-id"
+
+	requestIDField = "request_id"
+
+	// maxRequestIDLen limits the incoming ids, a longer one is replaced.
+	maxRequestIDLen = 128
+)
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context carrying the request id.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id put by the request id
+// interceptors.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+
+	return id, ok
+}
+
+// validRequestID accepts the printable ASCII ids up to maxRequestIDLen.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x20 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// requestID returns the context with the id of the incoming request, a new
+// UUID when the client has not sent a valid one.
+func requestID(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var id string
+
+	if v := md.Get(RequestIDHeader); len(v) > 0 && validRequestID(v[0]) {
+		id = v[0]
+	} else if u, err := types.NewUUIDv4(); err == nil {
+		id = u.String()
+	}
+
+	ctx = ContextWithRequestID(ctx, id)
+
+	return log.WithFields(ctx, log.String(requestIDField, id)), id
+}
+
+// RequestIDUnaryServerInterceptor reads or generates the request id, sends it
+// back in the x-request-id header and adds it to the logs of the call.
+func RequestIDUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, id := requestID(ctx)
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+
+		return handler(ctx, req)
+	}
+}
+
+// RequestIDStreamServerInterceptor is RequestIDUnaryServerInterceptor for the
+// streams.
+func RequestIDStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, id := requestID(ss.Context())
+
+		_ = ss.SetHeader(metadata.Pairs(RequestIDHeader, id))
+
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// WithRequestIDInterceptors chains the request id interceptors. Put them
+// after WithLogInterceptors, so the logs carry the request id.
+func WithRequestIDInterceptors() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(RequestIDUnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(RequestIDStreamServerInterceptor()),
+	}
+}
+
+// outgoingRequestID propagates the request id of the context to the call.
+func outgoingRequestID(ctx context.Context) context.Context {
+	id, ok := RequestIDFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	if md, _ := metadata.FromOutgoingContext(ctx); len(md.Get(RequestIDHeader)) > 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, RequestIDHeader, id)
+}
+
+// RequestIDUnaryClientInterceptor sends the request id of the context, so the
+// calls made while handling a request share its id.
+func RequestIDUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingRequestID(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+func RequestIDStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingRequestID(ctx), desc, cc, method, opts...)
+	}
+}
+
+func WithRequestIDClientInterceptors() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(RequestIDUnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(RequestIDStreamClientInterceptor()),
+	}
+}
@@ -0,0 +1,94 @@
+package grpcmidwr
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/0wnperception/go-helpers/pkg/log/logtest"
+	"github.com/0wnperception/go-helpers/pkg/types"
+)
+
+func TestRequestIDUnary(t *testing.T) {
+	interceptor := RequestIDUnaryServerInterceptor()
+
+	call := func(ctx context.Context) string {
+		var id string
+
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Call"},
+			func(ctx context.Context, _ interface{}) (interface{}, error) {
+				id, _ = RequestIDFromContext(ctx)
+
+				return nil, nil
+			})
+		require.NoError(t, err)
+
+		return id
+	}
+
+	incoming := func(id string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, id))
+	}
+
+	require.Equal(t, "req-1", call(incoming("req-1")))
+
+	for _, ctx := range []context.Context{context.Background(), incoming(strings.Repeat("a", 129)), incoming("bad\nid")} {
+		_, err := types.NewUUIDFromString(call(ctx))
+		require.NoError(t, err)
+	}
+}
+
+func TestRequestIDAccessLog(t *testing.T) {
+	rec := logtest.New(zap.DebugLevel)
+	logCtx := rec.Inject(context.Background())
+
+	srvOpts := append(WithLogInterceptors(logCtx, WithDefaultLogConfig(MethodLogConfig{NoPayloads: true})), WithRequestIDInterceptors()...)
+	srvOpts = append(srvOpts, WithAccessLogInterceptors(logCtx)...)
+
+	conn := startHealthServer(t, srvOpts, WithRequestIDClientInterceptors())
+	client := healthpb.NewHealthClient(conn)
+
+	var header metadata.MD
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDHeader, "req-1")
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "app"}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, []string{"req-1"}, header.Get(RequestIDHeader))
+
+	access := rec.Entries().Message(accessLogMessage)
+	require.Equal(t, 1, access.Field("request_id", "req-1").Field("method", "/grpc.health.v1.Health/Check").Field("code", "OK").Len())
+	require.Equal(t, 1, access.HasField("peer").HasField("duration").Len())
+
+	// the id of the handled request is sent with the downstream calls
+	_, err = client.Check(ContextWithRequestID(context.Background(), "req-2"), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+	require.Equal(t, 1, rec.Entries().Message(accessLogMessage).Field("request_id", "req-2").Len())
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+
+	stream, err := client.Watch(streamCtx, &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	header, err = stream.Header()
+	require.NoError(t, err)
+	require.Len(t, header.Get(RequestIDHeader), 1)
+
+	cancel()
+
+	require.Eventually(t, func() bool {
+		return rec.Entries().Message(accessLogMessage).Field("method", "/grpc.health.v1.Health/Watch").
+			Field("request_id", header.Get(RequestIDHeader)[0]).
+			Field("msgs_received", uint64(1)).Field("msgs_sent", uint64(1)).Len() == 1
+	}, time.Second, 10*time.Millisecond)
+}
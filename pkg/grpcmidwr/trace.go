@@ -0,0 +1,313 @@
+package grpcmidwr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const tracerName = "github.com/0wnperception/go-helpers/pkg/grpcmidwr"
+
+type traceConfig struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+type TraceOption func(*traceConfig)
+
+// WithTracerProvider sets the provider of the tracer, the global one by default.
+func WithTracerProvider(tp trace.TracerProvider) TraceOption {
+	return func(c *traceConfig) {
+		c.provider = tp
+	}
+}
+
+// WithPropagator sets the propagator of the trace context, W3C trace context
+// by default.
+func WithPropagator(p propagation.TextMapPropagator) TraceOption {
+	return func(c *traceConfig) {
+		c.propagator = p
+	}
+}
+
+func newTraceConfig(opts []TraceOption) *traceConfig {
+	c := &traceConfig{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	return c
+}
+
+func (c *traceConfig) tracer() trace.Tracer {
+	return c.provider.Tracer(tracerName)
+}
+
+// metadataCarrier adapts gRPC metadata to the propagators.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// rpcAttributes splits "/package.Service/Method" into the semantic attributes.
+func rpcAttributes(fullMethod string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.RPCSystemGRPC}
+
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if ok {
+		attrs = append(attrs, semconv.RPCService(service), semconv.RPCMethod(method))
+	}
+
+	return attrs
+}
+
+// serverErrorCode reports whether the code is a server error, client errors
+// leave the server span status unset.
+//
+//nolint:exhaustive
+func serverErrorCode(c codes.Code) bool {
+	switch c {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal,
+		codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+func endSpan(span trace.Span, err error, server bool) {
+	st, _ := status.FromError(err)
+
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+
+	if err != nil {
+		span.RecordError(err)
+
+		if !server || serverErrorCode(st.Code()) {
+			span.SetStatus(otelcodes.Error, st.Message())
+		}
+	}
+
+	span.End()
+}
+
+func (c *traceConfig) startServer(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	ctx = c.propagator.Extract(ctx, metadataCarrier(md))
+
+	return c.tracer().Start(ctx, fullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(rpcAttributes(fullMethod)...))
+}
+
+func (c *traceConfig) startClient(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	ctx, span := c.tracer().Start(ctx, fullMethod,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(rpcAttributes(fullMethod)...))
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+
+	c.propagator.Inject(ctx, metadataCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+// TraceUnaryServerInterceptor starts a server span named after the method with
+// the trace context extracted from the request metadata.
+func TraceUnaryServerInterceptor(opts ...TraceOption) grpc.UnaryServerInterceptor {
+	cfg := newTraceConfig(opts)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := cfg.startServer(ctx, info.FullMethod)
+
+		resp, err := handler(ctx, req)
+
+		endSpan(span, err, true)
+
+		return resp, err
+	}
+}
+
+// TraceStreamServerInterceptor is TraceUnaryServerInterceptor for streams, the
+// span lasts until the handler returns.
+func TraceStreamServerInterceptor(opts ...TraceOption) grpc.StreamServerInterceptor {
+	cfg := newTraceConfig(opts)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := cfg.startServer(ss.Context(), info.FullMethod)
+
+		err := handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+
+		endSpan(span, err, true)
+
+		return err
+	}
+}
+
+// TraceUnaryClientInterceptor starts a client span and injects the trace
+// context into the outgoing metadata.
+func TraceUnaryClientInterceptor(opts ...TraceOption) grpc.UnaryClientInterceptor {
+	cfg := newTraceConfig(opts)
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		ctx, span := cfg.startClient(ctx, method)
+
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+
+		endSpan(span, err, false)
+
+		return err
+	}
+}
+
+// TraceStreamClientInterceptor is TraceUnaryClientInterceptor for streams, the
+// span ends when the stream is finished or its context is done.
+func TraceStreamClientInterceptor(opts ...TraceOption) grpc.StreamClientInterceptor {
+	cfg := newTraceConfig(opts)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := cfg.startClient(ctx, method)
+
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			endSpan(span, err, false)
+
+			return nil, err
+		}
+
+		ts := &tracedClientStream{ClientStream: cs, span: span}
+
+		// the stream context is done when the stream is finished, the span is
+		// ended here only if the caller gave up before reading the status
+		go func() {
+			<-cs.Context().Done()
+
+			if err := ctx.Err(); err != nil {
+				ts.end(status.FromContextError(err).Err())
+			}
+		}()
+
+		return ts, nil
+	}
+}
+
+// WithTraceUnaryInterceptor chains TraceUnaryServerInterceptor. Put the trace
+// options before WithLogInterceptors, so the logs carry the trace ids.
+func WithTraceUnaryInterceptor(opts ...TraceOption) grpc.ServerOption {
+	return grpc.ChainUnaryInterceptor(TraceUnaryServerInterceptor(opts...))
+}
+
+// WithTraceStreamInterceptor chains TraceStreamServerInterceptor.
+func WithTraceStreamInterceptor(opts ...TraceOption) grpc.ServerOption {
+	return grpc.ChainStreamInterceptor(TraceStreamServerInterceptor(opts...))
+}
+
+func WithTraceInterceptors(opts ...TraceOption) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		WithTraceUnaryInterceptor(opts...),
+		WithTraceStreamInterceptor(opts...),
+	}
+}
+
+// WithTraceClientInterceptors chains the client trace interceptors.
+func WithTraceClientInterceptors(opts ...TraceOption) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(TraceUnaryClientInterceptor(opts...)),
+		grpc.WithChainStreamInterceptor(TraceStreamClientInterceptor(opts...)),
+	}
+}
+
+// contextServerStream overrides the context of the stream.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+type tracedClientStream struct {
+	grpc.ClientStream
+
+	span trace.Span
+	once sync.Once
+}
+
+func (s *tracedClientStream) end(err error) {
+	s.once.Do(func() {
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+
+		endSpan(s.span, err, false)
+	})
+}
+
+func (s *tracedClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.end(err)
+	}
+
+	return err
+}
+
+func (s *tracedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.end(err)
+	}
+
+	return err
+}
+
+func (s *tracedClientStream) Header() (metadata.MD, error) {
+	md, err := s.ClientStream.Header()
+	if err != nil {
+		s.end(err)
+	}
+
+	return md, err
+}
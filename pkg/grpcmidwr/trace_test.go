@@ -0,0 +1,153 @@
+package grpcmidwr
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/0wnperception/go-helpers/pkg/log/logtest"
+)
+
+// startHealthServer serves the health service over an in-memory listener and
+// returns a client connection.
+func startHealthServer(t *testing.T, srvOpts []grpc.ServerOption, dialOpts []grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+
+	srv := grpc.NewServer(srvOpts...)
+	hs := health.NewServer()
+	hs.SetServingStatus("app", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	t.Cleanup(srv.Stop)
+
+	dialOpts = append(dialOpts,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialOpts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return conn
+}
+
+func spanByKind(t *testing.T, spans []sdktrace.ReadOnlySpan, kind trace.SpanKind) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	for _, s := range spans {
+		if s.SpanKind() == kind {
+			return s
+		}
+	}
+
+	t.Fatalf("no %s span", kind)
+
+	return nil
+}
+
+func TestTraceInterceptors(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	rec := logtest.New(zap.DebugLevel)
+
+	srvOpts := append(WithTraceInterceptors(WithTracerProvider(tp)), WithLogInterceptors(rec.Inject(context.Background()))...)
+	conn := startHealthServer(t, srvOpts, WithTraceClientInterceptors(WithTracerProvider(tp)))
+
+	client := healthpb.NewHealthClient(conn)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+
+	srvSpan := spanByKind(t, spans, trace.SpanKindServer)
+	cliSpan := spanByKind(t, spans, trace.SpanKindClient)
+
+	require.Equal(t, "/grpc.health.v1.Health/Check", srvSpan.Name())
+	require.Equal(t, cliSpan.SpanContext().TraceID(), srvSpan.SpanContext().TraceID())
+	require.Equal(t, cliSpan.SpanContext().SpanID(), srvSpan.Parent().SpanID())
+	require.True(t, srvSpan.Parent().IsRemote())
+
+	// the log interceptor runs inside the server span
+	entries := rec.Entries().HasField("traceId")
+	require.NotZero(t, entries.Len())
+	require.Equal(t, 1, entries.Field("traceId", srvSpan.SpanContext().TraceID().String()).Len())
+}
+
+func TestTraceInterceptorsStatus(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	conn := startHealthServer(t, WithTraceInterceptors(WithTracerProvider(tp)), WithTraceClientInterceptors(WithTracerProvider(tp)))
+
+	client := healthpb.NewHealthClient(conn)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+
+	// not found is a client error, it leaves the server span status unset
+	require.Equal(t, otelcodes.Unset, spanByKind(t, spans, trace.SpanKindServer).Status().Code)
+	require.Equal(t, otelcodes.Error, spanByKind(t, spans, trace.SpanKindClient).Status().Code)
+}
+
+func TestTraceStreamInterceptors(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	conn := startHealthServer(t, WithTraceInterceptors(WithTracerProvider(tp)), WithTraceClientInterceptors(WithTracerProvider(tp)))
+
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "app"})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	cancel()
+
+	require.Eventually(t, func() bool {
+		return len(sr.Ended()) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	srvSpan := spanByKind(t, sr.Ended(), trace.SpanKindServer)
+	cliSpan := spanByKind(t, sr.Ended(), trace.SpanKindClient)
+
+	require.Equal(t, "/grpc.health.v1.Health/Watch", srvSpan.Name())
+	require.Equal(t, cliSpan.SpanContext().TraceID(), srvSpan.SpanContext().TraceID())
+	require.Equal(t, otelcodes.Error, cliSpan.Status().Code)
+}
@@ -0,0 +1,276 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/log/global"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	SinkStdout = "stdout"
+	SinkStderr = "stderr"
+	SinkFile   = "file"
+
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+)
+
+var ErrUnknownSink = errors.New("unknown log sink")
+
+// Config describes a logger built by NewWithConfig, unlike the zap config of
+// NewConfig. It can be read with pkg/config, e.g. as the `log` section of the
+// application config.
+type Config struct {
+	// Level is the base level, see Log.Level.
+	Level       string         `yaml:"level" default:"info"`
+	Development bool           `yaml:"development"`
+	Sampling    SamplingConfig `yaml:"sampling"`
+	Dedup       DedupConfig    `yaml:"dedup"`
+	OTel        OTelConfig     `yaml:"otel"`
+	// Redaction masks the fields of the entries of the logger, the struct tags
+	// are applied without it too.
+	Redaction *RedactionConfig `yaml:"redaction"`
+	// Sinks are written in tee mode. Without sinks the logger writes JSON to stdout.
+	Sinks []SinkConfig `yaml:"sinks"`
+}
+
+// SamplingConfig limits the number of entries with the same level and message
+// per tick: the first Initial entries are logged, then every Thereafter-th.
+type SamplingConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	Tick       time.Duration `yaml:"tick" default:"1s"`
+	Initial    int           `yaml:"initial" default:"100"`
+	Thereafter int           `yaml:"thereafter" default:"100"`
+}
+
+type SinkConfig struct {
+	// Type is one of stdout, stderr or file.
+	Type string `yaml:"type"`
+	// Encoding is json or console, json by default.
+	Encoding string `yaml:"encoding"`
+	// Color enables colored levels for the console encoding.
+	Color bool `yaml:"color"`
+	// Level is the minimal level of the sink on top of the base level.
+	Level string     `yaml:"level"`
+	File  FileConfig `yaml:"file"`
+}
+
+type FileConfig struct {
+	Path string `yaml:"path"`
+	// MaxSizeMB rotates the file when it grows bigger, zero disables it.
+	MaxSizeMB int `yaml:"maxSizeMb"`
+	// RotateEvery rotates the file when it gets older, zero disables it.
+	RotateEvery time.Duration `yaml:"rotateEvery"`
+	// MaxAge removes rotated files older than that, zero keeps them.
+	MaxAge time.Duration `yaml:"maxAge"`
+	// MaxBackups limits the number of rotated files, zero keeps all of them.
+	MaxBackups int  `yaml:"maxBackups"`
+	Compress   bool `yaml:"compress"`
+	// SyncInterval flushes the written entries to the disk periodically, zero
+	// leaves it to the OS. The file is always synced on Close.
+	SyncInterval time.Duration `yaml:"syncInterval"`
+}
+
+// NewWithConfig builds a logger writing to the configured sinks. Close of the
+// returned logger flushes the collapsed entries and closes the file sinks.
+func NewWithConfig(cfg Config, fields ...Field) (*Log, error) {
+	base := zap.NewAtomicLevel()
+
+	if cfg.Level != "" {
+		if err := base.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, fmt.Errorf("parse log level error: %w", err)
+		}
+	}
+
+	var redactor *Redactor
+
+	if cfg.Redaction != nil {
+		r, err := NewRedactor(*cfg.Redaction)
+		if err != nil {
+			return nil, err
+		}
+
+		redactor = r
+	}
+
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		sinks = []SinkConfig{{Type: SinkStdout}}
+	}
+
+	cores := make([]zapcore.Core, 0, len(sinks))
+	closers := make([]io.Closer, 0, len(sinks))
+
+	closeAll := func() {
+		for _, c := range closers {
+			_ = c.Close()
+		}
+	}
+
+	for _, s := range sinks {
+		core, closer, err := buildSink(s)
+		if err != nil {
+			closeAll()
+
+			return nil, err
+		}
+
+		cores = append(cores, core)
+
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+	}
+
+	if cfg.OTel.Export {
+		level := zap.NewAtomicLevelAt(zap.DebugLevel)
+
+		if cfg.OTel.Level != "" {
+			if err := level.UnmarshalText([]byte(cfg.OTel.Level)); err != nil {
+				closeAll()
+
+				return nil, fmt.Errorf("parse otel level error: %w", err)
+			}
+		}
+
+		cores = append(cores, NewOTelCore(global.GetLoggerProvider(), level))
+	}
+
+	core := zapcore.NewTee(cores...)
+
+	if cfg.Sampling.Enabled {
+		core = zapcore.NewSamplerWithOptions(core,
+			orDefault(cfg.Sampling.Tick, time.Second),
+			orDefault(cfg.Sampling.Initial, samplingInitial),
+			orDefault(cfg.Sampling.Thereafter, samplingThereafter))
+	}
+
+	var dedup *Deduper
+
+	if cfg.Dedup.Enabled {
+		dedup = NewDeduper(cfg.Dedup)
+		dedup.Start()
+
+		core = dedup.Wrap(core)
+		closers = append([]io.Closer{dedupCloser{dedup}}, closers...)
+	}
+
+	lv := newLevels(base)
+
+	opts := []zap.Option{
+		zap.AddCaller(),
+		zap.AddCallerSkip(1),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	}
+
+	if cfg.Development {
+		opts = append(opts, zap.Development(), zap.AddStacktrace(zap.WarnLevel))
+	} else {
+		opts = append(opts, zap.AddStacktrace(zap.ErrorLevel))
+	}
+
+	ll := &Log{
+		l:        zap.New(lv.wrap(newRedactCore(core, redactor)), opts...),
+		fields:   make(map[string]Field, len(fields)),
+		levels:   lv,
+		dedup:    dedup,
+		spans:    cfg.OTel.SpanEvents,
+		redactor: redactor,
+	}
+
+	for i := range fields {
+		ll.fields[fields[i].GetKey()] = fields[i]
+	}
+
+	if len(closers) > 0 {
+		ll.closer = closeAll
+	}
+
+	return ll, nil
+}
+
+// NewCtxWithConfig is NewCtx for a logger built by NewWithConfig.
+// //nolint: nonamedreturns
+func NewCtxWithConfig(ctx context.Context, name string, cfg Config, fields ...Field) (newCtx context.Context, cleanFn func(), err error) {
+	l, err := NewWithConfig(cfg, fields...)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+
+	l.l = l.l.Named(name)
+
+	return l.Inject(ctx), l.Close, nil
+}
+
+func buildSink(s SinkConfig) (zapcore.Core, io.Closer, error) {
+	encCfg := NewEncoderConfig()
+
+	var enc zapcore.Encoder
+
+	switch s.Encoding {
+	case "", EncodingJSON:
+		enc = zapcore.NewJSONEncoder(encCfg)
+	case EncodingConsole:
+		if s.Color {
+			encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+
+		encCfg.EncodeDuration = zapcore.StringDurationEncoder
+		enc = zapcore.NewConsoleEncoder(encCfg)
+	default:
+		return nil, nil, fmt.Errorf("unknown log encoding %q", s.Encoding)
+	}
+
+	// the base level is applied by levelCore, the sink level only narrows it
+	level := zap.NewAtomicLevelAt(zap.DebugLevel)
+
+	if s.Level != "" {
+		if err := level.UnmarshalText([]byte(s.Level)); err != nil {
+			return nil, nil, fmt.Errorf("parse sink level error: %w", err)
+		}
+	}
+
+	switch s.Type {
+	case "", SinkStdout:
+		return zapcore.NewCore(enc, zapcore.Lock(os.Stdout), level), nil, nil
+	case SinkStderr:
+		return zapcore.NewCore(enc, zapcore.Lock(os.Stderr), level), nil, nil
+	case SinkFile:
+		f, err := NewRotatingFile(s.File)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return zapcore.NewCore(enc, f, level), f, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownSink, s.Type)
+	}
+}
+
+func orDefault[T comparable](v, def T) T {
+	var zero T
+
+	if v == zero {
+		return def
+	}
+
+	return v
+}
+
+// dedupCloser flushes the deduper before the sinks are closed.
+type dedupCloser struct {
+	d *Deduper
+}
+
+func (c dedupCloser) Close() error {
+	c.d.Close()
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0wnperception/go-helpers/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	yml := `
+log:
+  level: debug
+  sinks:
+    - type: file
+      level: warn
+      file:
+        path: ` + filepath.Join(dir, "warn.log") + `
+    - type: file
+      encoding: console
+      file:
+        path: ` + filepath.Join(dir, "all.log") + `
+`
+
+	var c struct {
+		Log Config `yaml:"log"`
+	}
+
+	require.NoError(t, config.New(config.WithReader(strings.NewReader(yml))).Read(&c))
+	require.Equal(t, 100, c.Log.Sampling.Initial)
+
+	l, err := NewWithConfig(c.Log, String("app", "test"))
+	require.NoError(t, err)
+
+	l.Debug("debug message")
+	l.Warn("warn message")
+	l.Close()
+
+	warn, err := os.ReadFile(filepath.Join(dir, "warn.log"))
+	require.NoError(t, err)
+	require.NotContains(t, string(warn), "debug message")
+	require.Contains(t, string(warn), `"message":"warn message","app":"test"`)
+
+	all, err := os.ReadFile(filepath.Join(dir, "all.log"))
+	require.NoError(t, err)
+	require.Contains(t, string(all), "DEBUG")
+	require.Contains(t, string(all), "debug message")
+	require.Contains(t, string(all), "warn message")
+}
+
+func TestNewWithConfigSampling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	l, err := NewWithConfig(Config{
+		Sampling: SamplingConfig{Enabled: true, Initial: 2, Thereafter: 1000},
+		Sinks:    []SinkConfig{{Type: SinkFile, File: FileConfig{Path: path}}},
+	})
+	require.NoError(t, err)
+
+	for range 10 {
+		l.Info("hot path")
+	}
+
+	l.Close()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(string(data), "hot path"))
+}
+
+func TestNewWithConfigInvalid(t *testing.T) {
+	_, err := NewWithConfig(Config{Level: "loud"})
+	require.Error(t, err)
+
+	_, err = NewWithConfig(Config{Sinks: []SinkConfig{{Type: "syslog"}}})
+	require.ErrorIs(t, err, ErrUnknownSink)
+
+	_, err = NewWithConfig(Config{Sinks: []SinkConfig{{Encoding: "xml"}}})
+	require.Error(t, err)
+}
@@ -0,0 +1,311 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	RepeatedKey = "repeated"
+
+	defaultDedupWindow  = 10 * time.Second
+	defaultDedupBudget  = 1
+	defaultDedupMaxKeys = 10000
+)
+
+// DedupConfig describes how repeated entries are collapsed. Entries are the
+// same when they have the same level, logger name, message and values of the
+// key fields.
+type DedupConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Window is the period the budget is counted for.
+	Window time.Duration `yaml:"window" default:"10s"`
+	// Budget is the number of same entries logged as is per window, the rest
+	// are collapsed into one entry with the repeated count.
+	Budget int `yaml:"budget" default:"1"`
+	// Budgets overrides the budget by message.
+	Budgets map[string]int `yaml:"budgets"`
+	// KeyFields are the fields distinguishing the entries with the same message.
+	KeyFields []string `yaml:"keyFields"`
+	// MaxKeys limits the number of tracked entries, the others are logged as is.
+	MaxKeys int `yaml:"maxKeys" default:"10000"`
+}
+
+// Deduper collapses repeated entries of the cores it wraps.
+type Deduper struct {
+	window    time.Duration
+	budget    int
+	budgets   map[string]int
+	keyFields []string
+	maxKeys   int
+
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+
+	dropped atomic.Uint64
+
+	stop chan struct{}
+	done chan struct{}
+	now  func() time.Time
+}
+
+type dedupEntry struct {
+	started    time.Time
+	count      int
+	suppressed int
+
+	core   zapcore.Core
+	last   zapcore.Entry
+	fields []zapcore.Field
+}
+
+func NewDeduper(cfg DedupConfig) *Deduper {
+	return &Deduper{
+		window:    orDefault(cfg.Window, defaultDedupWindow),
+		budget:    orDefault(cfg.Budget, defaultDedupBudget),
+		budgets:   cfg.Budgets,
+		keyFields: cfg.KeyFields,
+		maxKeys:   orDefault(cfg.MaxKeys, defaultDedupMaxKeys),
+		entries:   make(map[string]*dedupEntry),
+		now:       time.Now,
+	}
+}
+
+// Start flushes the collapsed entries every window until Close.
+func (d *Deduper) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stop != nil {
+		return
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	d.stop, d.done = stop, done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(d.window)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				d.flush(false)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the background flush and writes all collapsed entries.
+func (d *Deduper) Close() {
+	d.mu.Lock()
+	stop, done := d.stop, d.done
+	d.stop = nil
+	d.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+
+	d.flush(true)
+}
+
+// Flush writes the collapsed entries whose window is over.
+func (d *Deduper) Flush() {
+	d.flush(false)
+}
+
+// Dropped returns the number of entries collapsed so far.
+func (d *Deduper) Dropped() uint64 {
+	return d.dropped.Load()
+}
+
+// Wrap returns a core collapsing the repeated entries before writing to core.
+func (d *Deduper) Wrap(core zapcore.Core) zapcore.Core {
+	return &dedupCore{Core: core, d: d}
+}
+
+func (d *Deduper) flush(all bool) {
+	now := d.now()
+
+	var summaries []*dedupEntry
+
+	d.mu.Lock()
+
+	for k, e := range d.entries {
+		if !all && now.Sub(e.started) < d.window {
+			continue
+		}
+
+		if e.suppressed > 0 {
+			summaries = append(summaries, e)
+		}
+
+		delete(d.entries, k)
+	}
+
+	d.mu.Unlock()
+
+	for _, e := range summaries {
+		writeSummary(e)
+	}
+}
+
+func (d *Deduper) budgetFor(msg string) int {
+	if b, ok := d.budgets[msg]; ok {
+		return b
+	}
+
+	return d.budget
+}
+
+func (d *Deduper) key(ent zapcore.Entry, fields []zapcore.Field) string {
+	var b strings.Builder
+
+	b.WriteString(ent.Level.String())
+	b.WriteByte(0)
+	b.WriteString(ent.LoggerName)
+	b.WriteByte(0)
+	b.WriteString(ent.Message)
+
+	if len(d.keyFields) == 0 {
+		return b.String()
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+
+	for _, f := range fields {
+		for _, k := range d.keyFields {
+			if f.Key == k {
+				f.AddTo(enc)
+			}
+		}
+	}
+
+	for _, k := range d.keyFields {
+		b.WriteByte(0)
+		b.WriteString(fmt.Sprint(enc.Fields[k]))
+	}
+
+	return b.String()
+}
+
+// admit reports whether the entry is written as is. It returns the summary of
+// the previous window to write first.
+func (d *Deduper) admit(core zapcore.Core, ent zapcore.Entry, fields []zapcore.Field) (bool, *dedupEntry) {
+	key := d.key(ent, fields)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var summary *dedupEntry
+
+	e, ok := d.entries[key]
+	if ok && ent.Time.Sub(e.started) >= d.window {
+		if e.suppressed > 0 {
+			summary = e
+		}
+
+		ok = false
+	}
+
+	if !ok {
+		if len(d.entries) >= d.maxKeys && summary == nil {
+			return true, nil
+		}
+
+		e = &dedupEntry{started: ent.Time}
+		d.entries[key] = e
+	}
+
+	e.count++
+	if e.count <= d.budgetFor(ent.Message) {
+		return true, summary
+	}
+
+	e.suppressed++
+	e.core = core
+	e.last = ent
+	e.fields = fields
+
+	d.dropped.Add(1)
+
+	return false, summary
+}
+
+func writeSummary(e *dedupEntry) {
+	fields := make([]zapcore.Field, 0, len(e.fields)+1)
+	fields = append(fields, e.fields...)
+	fields = append(fields, zap.Int(RepeatedKey, e.suppressed))
+
+	writeChecked(e.core, e.last, fields)
+}
+
+// writeChecked writes through Check, so the levels of the inner cores apply.
+func writeChecked(core zapcore.Core, ent zapcore.Entry, fields []zapcore.Field) {
+	if ce := core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
+type dedupCore struct {
+	zapcore.Core
+	d *Deduper
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), d: c.d}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ok, summary := c.d.admit(c.Core, ent, fields)
+
+	if summary != nil {
+		writeSummary(summary)
+	}
+
+	if ok {
+		writeChecked(c.Core, ent, fields)
+	}
+
+	return nil
+}
+
+// WithDedup returns a logger collapsing repeated entries with d. The caller
+// owns d: Start it for the periodic flush and Close it on exit.
+func (l *Log) WithDedup(d *Deduper) *Log {
+	newLog := l.clone()
+	newLog.l = l.l.WithOptions(zap.WrapCore(d.Wrap))
+	newLog.dedup = d
+
+	return newLog
+}
+
+// Dropped returns the number of entries collapsed by the deduper of the
+// logger, zero without one.
+func (l *Log) Dropped() uint64 {
+	if l.dedup != nil {
+		return l.dedup.Dropped()
+	}
+
+	return 0
+}
@@ -0,0 +1,109 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDedup(t *testing.T) {
+	var bs Buffer
+
+	d := NewDeduper(DedupConfig{Window: time.Hour, KeyFields: []string{"error"}})
+	l := newLevelsLog(&bs, zap.InfoLevel).WithDedup(d)
+
+	for range 5 {
+		l.Error("connection lost", Error(errors.New("eof")))
+	}
+
+	l.Error("connection lost", Error(errors.New("timeout")))
+	l.Info("other")
+
+	require.Len(t, bs.Lines(), 3)
+	require.Equal(t, uint64(4), l.Dropped())
+
+	d.Close()
+
+	lines := bs.Lines()
+	require.Len(t, lines, 4)
+	require.Contains(t, lines[3], `"message":"connection lost"`)
+	require.Contains(t, lines[3], `"error":"eof"`)
+	require.Contains(t, lines[3], `"repeated":4`)
+}
+
+func TestDedupWindow(t *testing.T) {
+	var bs Buffer
+
+	d := NewDeduper(DedupConfig{
+		Window:  time.Minute,
+		Budget:  2,
+		Budgets: map[string]int{"noisy": 1},
+	})
+	l := newLevelsLog(&bs, zap.InfoLevel).WithDedup(d)
+
+	for range 3 {
+		l.Warn("noisy")
+		l.Warn("budget")
+	}
+
+	require.Len(t, bs.Lines(), 3)
+
+	d.Flush()
+	require.Len(t, bs.Lines(), 3)
+
+	d.now = func() time.Time { return time.Now().Add(time.Minute) }
+	d.Flush()
+
+	lines := bs.Lines()
+	require.Len(t, lines, 5)
+	require.Equal(t, uint64(3), d.Dropped())
+
+	for _, line := range lines[3:] {
+		require.Contains(t, line, `"repeated":`)
+	}
+}
+
+func TestDedupMaxKeys(t *testing.T) {
+	var bs Buffer
+
+	d := NewDeduper(DedupConfig{Window: time.Hour, MaxKeys: 1})
+	l := newLevelsLog(&bs, zap.InfoLevel).WithDedup(d)
+
+	l.Info("first")
+	l.Info("first")
+	l.Info("second")
+	l.Info("second")
+
+	require.Len(t, bs.Lines(), 3)
+	require.Equal(t, uint64(1), d.Dropped())
+}
+
+func TestNewWithConfigDedup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	l, err := NewWithConfig(Config{
+		Sinks: []SinkConfig{{Type: SinkFile, File: FileConfig{Path: path}}},
+		Dedup: DedupConfig{Enabled: true},
+	})
+	require.NoError(t, err)
+
+	for range 3 {
+		l.Warn("flapping")
+	}
+
+	require.Equal(t, uint64(2), l.Dropped())
+	l.Close()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[1], `"repeated":2`)
+}
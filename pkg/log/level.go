@@ -0,0 +1,306 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var errEmptyLevelName = errors.New("logger name is required")
+
+// noOverride is the minimum override level when there are no overrides, it is
+// above every level.
+const noOverride = int32(zapcore.InvalidLevel)
+
+// levels holds the base level shared by all loggers derived from one root
+// and the per-name overrides. The entries below the base and the overrides
+// are rejected without a lock, the override resolved for a logger name is
+// cached until the overrides change.
+type levels struct {
+	base zap.AtomicLevel
+
+	minOverride atomic.Int32
+	resolved    atomic.Pointer[sync.Map]
+
+	mu        sync.RWMutex
+	overrides map[string]zapcore.Level
+}
+
+// resolvedLevel is the override matching a logger name, if any.
+type resolvedLevel struct {
+	level zapcore.Level
+	ok    bool
+}
+
+func newLevels(base zap.AtomicLevel) *levels {
+	lv := &levels{
+		base:      base,
+		overrides: make(map[string]zapcore.Level),
+	}
+
+	lv.minOverride.Store(noOverride)
+	lv.resolved.Store(&sync.Map{})
+
+	return lv
+}
+
+// matchName reports whether the override key is a dot-separated part of the
+// logger name, so "depsgraph" matches "app.depsgraph" and "depsgraph.rank".
+func matchName(name, key string) bool {
+	return name == key ||
+		strings.HasPrefix(name, key+".") ||
+		strings.HasSuffix(name, "."+key) ||
+		strings.Contains(name, "."+key+".")
+}
+
+func (lv *levels) levelFor(name string) zapcore.Level {
+	if lv.minOverride.Load() == noOverride {
+		return lv.base.Level()
+	}
+
+	// the cache is loaded before the overrides are read, so a result resolved
+	// with the overrides replaced meanwhile goes to the dropped cache
+	cache := lv.resolved.Load()
+
+	v, ok := cache.Load(name)
+	if !ok {
+		v, _ = cache.LoadOrStore(name, lv.resolve(name))
+	}
+
+	if r := v.(resolvedLevel); r.ok { //nolint:forcetypeassert
+		return r.level
+	}
+
+	return lv.base.Level()
+}
+
+// resolve returns the longest override matching the logger name.
+func (lv *levels) resolve(name string) resolvedLevel {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	var (
+		best string
+		res  resolvedLevel
+	)
+
+	for k, v := range lv.overrides {
+		if len(k) > len(best) && matchName(name, k) {
+			best, res = k, resolvedLevel{level: v, ok: true}
+		}
+	}
+
+	return res
+}
+
+func (lv *levels) enabled(name string, l zapcore.Level) bool {
+	return l >= lv.minLevel() && l >= lv.levelFor(name)
+}
+
+// minLevel returns the most verbose level over the base and the overrides.
+func (lv *levels) minLevel() zapcore.Level {
+	return min(lv.base.Level(), zapcore.Level(lv.minOverride.Load()))
+}
+
+func (lv *levels) set(name string, l zapcore.Level) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	lv.overrides[name] = l
+	lv.changed()
+}
+
+func (lv *levels) unset(name string) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	delete(lv.overrides, name)
+	lv.changed()
+}
+
+// changed updates the minimum override and drops the resolved levels, it is
+// called with the write lock held.
+func (lv *levels) changed() {
+	m := noOverride
+	for _, v := range lv.overrides {
+		m = min(m, int32(v))
+	}
+
+	lv.minOverride.Store(m)
+	lv.resolved.Store(&sync.Map{})
+}
+
+func (lv *levels) named() map[string]zapcore.Level {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	res := make(map[string]zapcore.Level, len(lv.overrides))
+	for k, v := range lv.overrides {
+		res[k] = v
+	}
+
+	return res
+}
+
+func (lv *levels) wrap(c zapcore.Core) zapcore.Core {
+	return &levelCore{Core: c, levels: lv}
+}
+
+// levelCore filters entries by the level resolved for the logger name on top
+// of the level of the wrapped core.
+type levelCore struct {
+	zapcore.Core
+	levels *levels
+}
+
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	return l >= c.levels.minLevel() && c.Core.Enabled(l)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.levels.enabled(ent.LoggerName, ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+
+	return ce
+}
+
+func (l *Log) enabled(lvl zapcore.Level) bool {
+	if l.levels != nil && !l.levels.enabled(l.l.Name(), lvl) {
+		return false
+	}
+
+	return l.l.Core().Enabled(lvl)
+}
+
+// Level returns the base level shared by the loggers derived from the same
+// root. The level of a logger not created by this package is detached.
+func (l *Log) Level() zap.AtomicLevel {
+	if l.levels != nil {
+		return l.levels.base
+	}
+
+	return zap.NewAtomicLevel()
+}
+
+// SetNamedLevel overrides the level for the loggers whose name contains name
+// as a dot-separated part. The longest matching override wins.
+func (l *Log) SetNamedLevel(name string, lvl zapcore.Level) {
+	if l.levels != nil {
+		l.levels.set(name, lvl)
+	}
+}
+
+// UnsetNamedLevel removes the override set by SetNamedLevel.
+func (l *Log) UnsetNamedLevel(name string) {
+	if l.levels != nil {
+		l.levels.unset(name)
+	}
+}
+
+// NamedLevels returns a copy of the level overrides.
+func (l *Log) NamedLevels() map[string]zapcore.Level {
+	if l.levels != nil {
+		return l.levels.named()
+	}
+
+	return map[string]zapcore.Level{}
+}
+
+// shiftLevel makes the base level more (negative delta) or less verbose,
+// staying between debug and error.
+func (l *Log) shiftLevel(delta int) zapcore.Level {
+	lvl := l.Level()
+
+	next := zapcore.Level(int(lvl.Level()) + delta)
+	next = max(zapcore.DebugLevel, min(zapcore.ErrorLevel, next))
+
+	lvl.SetLevel(next)
+
+	return next
+}
+
+type levelPayload struct {
+	Name  string            `json:"name,omitempty"`
+	Level *zapcore.Level    `json:"level,omitempty"`
+	Named map[string]string `json:"named,omitempty"`
+}
+
+// LevelHandler returns an HTTP handler for the logger levels.
+//
+//	GET    returns {"level":"info","named":{"depsgraph":"debug"}}
+//	PUT    {"level":"debug"} sets the base level
+//	PUT    {"name":"depsgraph","level":"debug"} sets a named override
+//	DELETE ?name=depsgraph removes a named override
+func (l *Log) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var req levelPayload
+
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeLevelError(w, http.StatusBadRequest, err)
+
+				return
+			}
+
+			if req.Level == nil {
+				writeLevelError(w, http.StatusBadRequest, errors.New("level is required"))
+
+				return
+			}
+
+			if req.Name != "" {
+				l.SetNamedLevel(req.Name, *req.Level)
+			} else {
+				l.Level().SetLevel(*req.Level)
+			}
+		case http.MethodDelete:
+			name := r.URL.Query().Get("name")
+			if name == "" {
+				writeLevelError(w, http.StatusBadRequest, errEmptyLevelName)
+
+				return
+			}
+
+			l.UnsetNamedLevel(name)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			writeLevelError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+
+			return
+		}
+
+		base := l.Level().Level()
+		resp := levelPayload{Level: &base}
+
+		if named := l.NamedLevels(); len(named) > 0 {
+			resp.Named = make(map[string]string, len(named))
+
+			for k, v := range named {
+				resp.Named[k] = v.String()
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func writeLevelError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
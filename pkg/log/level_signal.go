@@ -0,0 +1,38 @@
+//go:build !windows
+
+package log
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// NotifyLevelSignals makes SIGUSR1 increase and SIGUSR2 decrease the verbosity
+// of the base level. The returned function stops handling the signals.
+func (l *Log) NotifyLevelSignals() (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		for {
+			select {
+			case s := <-ch:
+				if s == syscall.SIGUSR1 {
+					l.shiftLevel(-1)
+				} else {
+					l.shiftLevel(1)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
@@ -0,0 +1,6 @@
+package log
+
+// NotifyLevelSignals is a no-op, there are no user signals on windows.
+func (l *Log) NotifyLevelSignals() (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func newLevelsLog(bs *Buffer, lvl zapcore.Level) *Log {
+	encCfg := NewEncoderConfig()
+	encCfg.EncodeTime = testTimeEncoder
+
+	lv := newLevels(zap.NewAtomicLevelAt(lvl))
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encCfg), bs, zap.DebugLevel)
+
+	return &Log{
+		l:      zap.New(lv.wrap(newRedactCore(core, nil))),
+		fields: make(map[string]Field),
+		levels: lv,
+	}
+}
+
+func TestNamedLevels(t *testing.T) {
+	var bs Buffer
+
+	root := newLevelsLog(&bs, zap.InfoLevel).Named("app")
+	graph := root.Named("depsgraph")
+
+	ctx := graph.Inject(context.Background())
+
+	Debug(ctx, "hidden")
+	require.False(t, DebugEnabled(ctx))
+
+	root.SetNamedLevel("depsgraph", zap.DebugLevel)
+	require.True(t, DebugEnabled(ctx))
+	require.False(t, root.DebugEnabled())
+
+	Debug(ctx, "graph debug")
+	root.Debug("root debug")
+	root.Named("other").Debug("other debug")
+	graph.Z().Debug("zap debug")
+
+	root.UnsetNamedLevel("depsgraph")
+	Debug(ctx, "hidden again")
+
+	lines := bs.Lines()
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"message":"graph debug"`)
+	require.Contains(t, lines[1], `"message":"zap debug"`)
+
+	root.Level().SetLevel(zap.DebugLevel)
+	require.True(t, root.DebugEnabled())
+}
+
+func TestLevelsResolvedCache(t *testing.T) {
+	lv := newLevels(zap.NewAtomicLevelAt(zap.InfoLevel))
+
+	require.False(t, lv.enabled("app.depsgraph", zap.DebugLevel))
+
+	lv.set("depsgraph", zap.DebugLevel)
+	require.True(t, lv.enabled("app.depsgraph", zap.DebugLevel))
+	require.False(t, lv.enabled("app.other", zap.DebugLevel), "rejected by the base level")
+
+	lv.set("app.depsgraph", zap.WarnLevel)
+	require.False(t, lv.enabled("app.depsgraph", zap.InfoLevel), "the longer override replaces the cached one")
+
+	lv.base.SetLevel(zap.ErrorLevel)
+	require.True(t, lv.enabled("app.depsgraph", zap.WarnLevel))
+	require.False(t, lv.enabled("app.other", zap.WarnLevel), "the base level is not cached")
+
+	lv.unset("app.depsgraph")
+	lv.unset("depsgraph")
+	require.Equal(t, zap.ErrorLevel, lv.minLevel())
+	require.False(t, lv.enabled("app.depsgraph", zap.WarnLevel))
+}
+
+func TestShiftLevel(t *testing.T) {
+	var bs Buffer
+
+	l := newLevelsLog(&bs, zap.InfoLevel)
+
+	require.Equal(t, zap.DebugLevel, l.shiftLevel(-1))
+	require.Equal(t, zap.DebugLevel, l.shiftLevel(-1))
+	require.Equal(t, zap.InfoLevel, l.shiftLevel(1))
+	require.Equal(t, zap.WarnLevel, l.shiftLevel(1))
+	require.Equal(t, zap.ErrorLevel, l.shiftLevel(1))
+	require.Equal(t, zap.ErrorLevel, l.shiftLevel(1))
+}
+
+func TestLevelHandler(t *testing.T) {
+	var bs Buffer
+
+	l := newLevelsLog(&bs, zap.InfoLevel)
+	h := l.LevelHandler()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"level":"info"}`, rec.Body.String())
+
+	rec = do(http.MethodPut, "/", `{"level":"warn"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, zap.WarnLevel, l.Level().Level())
+
+	rec = do(http.MethodPut, "/", `{"name":"depsgraph","level":"debug"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"level":"warn","named":{"depsgraph":"debug"}}`, rec.Body.String())
+
+	rec = do(http.MethodDelete, "/?name=depsgraph", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, l.NamedLevels())
+
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/", `{"level":"loud"}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodDelete, "/", "").Code)
+	require.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPatch, "/", "").Code)
+}
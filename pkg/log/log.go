@@ -54,6 +54,14 @@ func Nop() *Log {
 type Log struct {
 	l      *zap.Logger
 	fields map[string]Field
+	levels *levels
+	dedup  *Deduper
+	// spans records Warn and Err entries as events of the context span
+	spans bool
+	// redactor masks the fields of the span events, the core masks the rest
+	redactor *Redactor
+	// closer releases the sinks, it is set only on the root logger
+	closer func()
 }
 
 type Field struct {
@@ -82,14 +90,7 @@ func (l *Log) With(fields ...Field) *Log {
 		return l
 	}
 
-	newLog := &Log{
-		l:      l.l,
-		fields: make(map[string]Field, len(fields)),
-	}
-
-	for k, v := range l.fields {
-		newLog.fields[k] = v
-	}
+	newLog := l.clone()
 
 	for i := range fields {
 		newLog.fields[fields[i].GetKey()] = fields[i]
@@ -103,15 +104,26 @@ func (l *Log) Named(s string) *Log {
 		return l
 	}
 
+	newLog := l.clone()
+	newLog.l = l.l.Named(s)
+
+	return newLog
+}
+
+// clone returns a copy of the logger sharing the levels and the deduper with
+// its own copy of the fields. The closer stays with the root logger.
+func (l *Log) clone() *Log {
 	newLog := &Log{
-		l:      l.l.Named(s),
-		fields: make(map[string]Field),
+		l:        l.l,
+		fields:   make(map[string]Field, len(l.fields)),
+		levels:   l.levels,
+		dedup:    l.dedup,
+		spans:    l.spans,
+		redactor: l.redactor,
 	}
 
-	if l.fields != nil {
-		for k, v := range l.fields {
-			newLog.fields[k] = v
-		}
+	for k, v := range l.fields {
+		newLog.fields[k] = v
 	}
 
 	return newLog
@@ -417,25 +429,25 @@ func (l *Log) buildLoggerFields(ctx context.Context, fields ...Field) []zap.Fiel
 }
 
 func (l *Log) Error(msg string, fields ...Field) {
-	if l.l.Core().Enabled(zapcore.ErrorLevel) {
+	if l.enabled(zapcore.ErrorLevel) {
 		l.l.Error(msg, l.buildLoggerFields(context.Background(), fields...)...)
 	}
 }
 
 func (l *Log) Debug(msg string, fields ...Field) {
-	if l.l.Core().Enabled(zapcore.DebugLevel) {
+	if l.enabled(zapcore.DebugLevel) {
 		l.l.Debug(msg, l.buildLoggerFields(context.Background(), fields...)...)
 	}
 }
 
 func (l *Log) Info(msg string, fields ...Field) {
-	if l.l.Core().Enabled(zapcore.InfoLevel) {
+	if l.enabled(zapcore.InfoLevel) {
 		l.l.Info(msg, l.buildLoggerFields(context.Background(), fields...)...)
 	}
 }
 
 func (l *Log) Warn(msg string, fields ...Field) {
-	if l.l.Core().Enabled(zapcore.WarnLevel) {
+	if l.enabled(zapcore.WarnLevel) {
 		l.l.Warn(msg, l.buildLoggerFields(context.Background(), fields...)...)
 	}
 }
@@ -446,6 +458,10 @@ func (l *Log) Fatal(msg string, fields ...Field) {
 
 func (l *Log) Close() {
 	_ = l.l.Sync()
+
+	if l.closer != nil {
+		l.closer()
+	}
 }
 
 func (l *Log) Z() *zap.Logger {
@@ -453,7 +469,7 @@ func (l *Log) Z() *zap.Logger {
 }
 
 func (l *Log) DebugEnabled() bool {
-	return l.l.Core().Enabled(zapcore.DebugLevel)
+	return l.enabled(zapcore.DebugLevel)
 }
 
 func DebugEnabled(ctx context.Context) bool {
@@ -467,7 +483,7 @@ func DebugEnabled(ctx context.Context) bool {
 }
 
 func (l *Log) WarnEnabled() bool {
-	return l.l.Core().Enabled(zapcore.WarnLevel)
+	return l.enabled(zapcore.WarnLevel)
 }
 
 func WarnEnabled(ctx context.Context) bool {
@@ -481,7 +497,7 @@ func WarnEnabled(ctx context.Context) bool {
 }
 
 func (l *Log) InfoEnabled() bool {
-	return l.l.Core().Enabled(zapcore.InfoLevel)
+	return l.enabled(zapcore.InfoLevel)
 }
 
 func InfoEnabled(ctx context.Context) bool {
@@ -495,7 +511,7 @@ func InfoEnabled(ctx context.Context) bool {
 }
 
 func (l *Log) ErrorEnabled() bool {
-	return l.l.Core().Enabled(zapcore.ErrorLevel)
+	return l.enabled(zapcore.ErrorLevel)
 }
 
 func ErrorEnabled(ctx context.Context) bool {
@@ -534,7 +550,13 @@ func New(debug bool, fields ...Field) *Log {
 		cfg.Development = true
 	}
 
-	logger, err := cfg.Build()
+	// the core accepts everything, levels filters entries by logger name
+	lv := newLevels(cfg.Level)
+	cfg.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+
+	logger, err := cfg.Build(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return lv.wrap(newRedactCore(c, nil))
+	}))
 	if err != nil {
 		panic(err)
 	}
@@ -544,6 +566,7 @@ func New(debug bool, fields ...Field) *Log {
 	ll := &Log{
 		l:      logger,
 		fields: make(map[string]Field),
+		levels: lv,
 	}
 
 	for i := range fields {
@@ -553,18 +576,28 @@ func New(debug bool, fields ...Field) *Log {
 	return ll
 }
 
-func (l *Log) WithCallerSkip(skip int) *Log {
-	newLog := &Log{
-		l:      l.l.WithOptions(zap.AddCallerSkip(skip)),
-		fields: make(map[string]Field),
+// NewWithCore creates a logger writing to the core. The core level is the
+// base level, it can be narrowed with Level and SetNamedLevel.
+func NewWithCore(core zapcore.Core, fields ...Field) *Log {
+	lv := newLevels(zap.NewAtomicLevelAt(zap.DebugLevel))
+
+	ll := &Log{
+		l:      zap.New(lv.wrap(newRedactCore(core, nil)), zap.AddCaller(), zap.AddCallerSkip(1)),
+		fields: make(map[string]Field, len(fields)),
+		levels: lv,
 	}
 
-	if l.fields != nil {
-		for k, v := range l.fields {
-			newLog.fields[k] = v
-		}
+	for i := range fields {
+		ll.fields[fields[i].GetKey()] = fields[i]
 	}
 
+	return ll
+}
+
+func (l *Log) WithCallerSkip(skip int) *Log {
+	newLog := l.clone()
+	newLog.l = l.l.WithOptions(zap.AddCallerSkip(skip))
+
 	return newLog
 }
 
@@ -590,15 +623,17 @@ func FromContext(ctx context.Context) *Log {
 
 func Err(ctx context.Context, msg string, fields ...Field) {
 	if l, ok := ctx.Value(loggerKey).(*Log); ok {
-		if l.l.Core().Enabled(zapcore.ErrorLevel) {
-			l.l.Error(msg, l.buildLoggerFields(ctx, fields...)...)
+		if l.enabled(zapcore.ErrorLevel) {
+			zf := l.buildLoggerFields(ctx, fields...)
+			l.l.Error(msg, zf...)
+			l.spanEvent(ctx, zapcore.ErrorLevel, msg, zf)
 		}
 	}
 }
 
 func Debug(ctx context.Context, msg string, fields ...Field) {
 	if l, ok := ctx.Value(loggerKey).(*Log); ok {
-		if l.l.Core().Enabled(zapcore.DebugLevel) {
+		if l.enabled(zapcore.DebugLevel) {
 			l.l.Debug(msg, l.buildLoggerFields(ctx, fields...)...)
 		}
 	}
@@ -606,7 +641,7 @@ func Debug(ctx context.Context, msg string, fields ...Field) {
 
 func Info(ctx context.Context, msg string, fields ...Field) {
 	if l, ok := ctx.Value(loggerKey).(*Log); ok {
-		if l.l.Core().Enabled(zapcore.InfoLevel) {
+		if l.enabled(zapcore.InfoLevel) {
 			l.l.Info(msg, l.buildLoggerFields(ctx, fields...)...)
 		}
 	}
@@ -614,8 +649,10 @@ func Info(ctx context.Context, msg string, fields ...Field) {
 
 func Warn(ctx context.Context, msg string, fields ...Field) {
 	if l, ok := ctx.Value(loggerKey).(*Log); ok {
-		if l.l.Core().Enabled(zapcore.WarnLevel) {
-			l.l.Warn(msg, l.buildLoggerFields(ctx, fields...)...)
+		if l.enabled(zapcore.WarnLevel) {
+			zf := l.buildLoggerFields(ctx, fields...)
+			l.l.Warn(msg, zf...)
+			l.spanEvent(ctx, zapcore.WarnLevel, msg, zf)
 		}
 	}
 }
@@ -630,7 +667,7 @@ func Fatal(ctx context.Context, msg string, fields ...Field) {
 
 func Panic(ctx context.Context, msg string, fields ...Field) {
 	if l, ok := ctx.Value(loggerKey).(*Log); ok {
-		if l.l.Core().Enabled(zapcore.PanicLevel) {
+		if l.enabled(zapcore.PanicLevel) {
 			l.l.Panic(msg, l.buildLoggerFields(ctx, fields...)...)
 		}
 	}
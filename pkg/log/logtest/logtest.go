@@ -0,0 +1,179 @@
+// Package logtest provides a *log.Log that keeps the entries in memory, so
+// tests can assert on what the code under test has logged.
+package logtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+// Recorder captures the entries written by its logger and the loggers derived
+// from it.
+type Recorder struct {
+	log  *log.Log
+	logs *observer.ObservedLogs
+}
+
+// Entry is a captured log entry. Fields contains the fields added with
+// log.WithFields and the fields of the call.
+type Entry struct {
+	Level      zapcore.Level
+	Time       time.Time
+	LoggerName string
+	Message    string
+	Fields     map[string]any
+}
+
+// Field returns the value of the field with the key.
+func (e Entry) Field(key string) (any, bool) {
+	v, ok := e.Fields[key]
+
+	return v, ok
+}
+
+// Entries is a list of captured entries with filters.
+type Entries []Entry
+
+// New creates a recorder capturing entries at level and above.
+func New(level zapcore.Level) *Recorder {
+	core, logs := observer.New(level)
+
+	return &Recorder{
+		log:  log.NewWithCore(core),
+		logs: logs,
+	}
+}
+
+// NewContext creates a recorder capturing all levels and injects its logger
+// into the context.
+func NewContext(ctx context.Context) (context.Context, *Recorder) {
+	r := New(zapcore.DebugLevel)
+
+	return r.Inject(ctx), r
+}
+
+// Log returns the recording logger.
+func (r *Recorder) Log() *log.Log {
+	return r.log
+}
+
+// Inject puts the recording logger into the context.
+func (r *Recorder) Inject(ctx context.Context) context.Context {
+	return r.log.Inject(ctx)
+}
+
+// Entries returns a snapshot of the captured entries.
+func (r *Recorder) Entries() Entries {
+	logged := r.logs.All()
+	res := make(Entries, 0, len(logged))
+
+	for _, e := range logged {
+		res = append(res, Entry{
+			Level:      e.Level,
+			Time:       e.Time,
+			LoggerName: e.LoggerName,
+			Message:    e.Message,
+			Fields:     e.ContextMap(),
+		})
+	}
+
+	return res
+}
+
+// Len returns the number of captured entries.
+func (r *Recorder) Len() int {
+	return r.logs.Len()
+}
+
+// Reset drops the captured entries.
+func (r *Recorder) Reset() {
+	r.logs.TakeAll()
+}
+
+// Filter returns the entries matching f.
+func (es Entries) Filter(f func(Entry) bool) Entries {
+	res := make(Entries, 0, len(es))
+
+	for _, e := range es {
+		if f(e) {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+// Level returns the entries with the level.
+func (es Entries) Level(l zapcore.Level) Entries {
+	return es.Filter(func(e Entry) bool {
+		return e.Level == l
+	})
+}
+
+// Message returns the entries with exactly the message.
+func (es Entries) Message(msg string) Entries {
+	return es.Filter(func(e Entry) bool {
+		return e.Message == msg
+	})
+}
+
+// MessageContains returns the entries with messages containing s.
+func (es Entries) MessageContains(s string) Entries {
+	return es.Filter(func(e Entry) bool {
+		return strings.Contains(e.Message, s)
+	})
+}
+
+// Field returns the entries having the field with the value. Values of
+// different types are equal if they print the same, so Int("n", 1) matches 1.
+func (es Entries) Field(key string, value any) Entries {
+	return es.Filter(func(e Entry) bool {
+		v, ok := e.Fields[key]
+
+		return ok && equal(v, value)
+	})
+}
+
+// HasField returns the entries having the field with any value.
+func (es Entries) HasField(key string) Entries {
+	return es.Filter(func(e Entry) bool {
+		_, ok := e.Fields[key]
+
+		return ok
+	})
+}
+
+// Messages returns the messages of the entries.
+func (es Entries) Messages() []string {
+	res := make([]string, 0, len(es))
+	for _, e := range es {
+		res = append(res, e.Message)
+	}
+
+	return res
+}
+
+// Len returns the number of entries.
+func (es Entries) Len() int {
+	return len(es)
+}
+
+func equal(got, want any) bool {
+	if reflect.DeepEqual(got, want) {
+		return true
+	}
+
+	if err, ok := want.(error); ok {
+		want = err.Error()
+	}
+
+	return fmt.Sprint(got) == fmt.Sprint(want)
+}
@@ -0,0 +1,54 @@
+package logtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx, rec := NewContext(context.Background())
+
+	ctx = log.WithFields(ctx, log.String("consumer", "127.0.0.1"), log.Int("attempt", 1))
+
+	log.Debug(ctx, "connecting")
+	log.Err(ctx, "connection failed", log.Error(errors.New("refused")))
+	log.FromContext(ctx).Named("mqtt").Warn("reconnecting", log.Int("attempt", 2))
+
+	require.Equal(t, 3, rec.Len())
+
+	entries := rec.Entries()
+	require.Equal(t, []string{"connection failed"}, entries.Level(zapcore.ErrorLevel).Messages())
+	require.Equal(t, 3, entries.Field("consumer", "127.0.0.1").Len())
+	require.Equal(t, 1, entries.Field("error", errors.New("refused")).Len())
+	require.Equal(t, 2, entries.Field("attempt", 1).Len())
+	require.Equal(t, 1, entries.MessageContains("reconnect").Field("attempt", 2).Len())
+	require.Equal(t, 0, entries.HasField("missing").Len())
+
+	warn := entries.Message("reconnecting")[0]
+	require.Equal(t, "mqtt", warn.LoggerName)
+
+	v, ok := warn.Field("attempt")
+	require.True(t, ok)
+	require.Equal(t, int64(2), v)
+
+	rec.Reset()
+	require.Equal(t, 0, rec.Len())
+}
+
+func TestRecorderLevel(t *testing.T) {
+	rec := New(zapcore.InfoLevel)
+	ctx := rec.Inject(context.Background())
+
+	require.False(t, log.DebugEnabled(ctx))
+
+	log.Debug(ctx, "hidden")
+	log.Info(ctx, "shown")
+
+	require.Equal(t, []string{"shown"}, rec.Entries().Messages())
+}
@@ -0,0 +1,324 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// OTelScope is the instrumentation scope of the exported log records.
+	OTelScope = "github.com/0wnperception/go-helpers/pkg/log"
+
+	severityKey   = "log.severity"
+	loggerNameKey = "logger"
+	callerKey     = "caller"
+	stackKey      = "stacktrace"
+)
+
+// OTelConfig describes how the entries reach the tracing backend.
+type OTelConfig struct {
+	// SpanEvents records Warn and Err entries as events of the span found in
+	// the context, Err also marks the span status as error.
+	SpanEvents bool `yaml:"spanEvents"`
+	// Export emits the entries as OTel log records through the global logger
+	// provider.
+	Export bool `yaml:"export"`
+	// Level is the minimal level of the exported entries.
+	Level string `yaml:"level"`
+}
+
+// WithSpanEvents returns a logger recording Warn and Err entries logged with a
+// context as events of the span in the context.
+func (l *Log) WithSpanEvents() *Log {
+	newLog := l.clone()
+	newLog.spans = true
+
+	return newLog
+}
+
+func (l *Log) spanEvent(ctx context.Context, lvl zapcore.Level, msg string, fields []zap.Field) {
+	if !l.spans || lvl < zapcore.WarnLevel {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(fields)+1)
+	attrs = append(attrs, attribute.String(severityKey, lvl.CapitalString()))
+
+	r := l.redactor
+	if r == nil {
+		r = tagRedactor
+	}
+
+	encoded := encodeFields(r.fields(fields))
+	for _, k := range slices.Sorted(maps.Keys(encoded)) {
+		attrs = append(attrs, spanAttribute(k, encoded[k]))
+	}
+
+	span.AddEvent(msg, trace.WithAttributes(attrs...))
+
+	if lvl >= zapcore.ErrorLevel {
+		span.SetStatus(codes.Error, msg)
+	}
+}
+
+// encodeFields encodes the fields into a map without the trace and span ids,
+// they are carried by the span itself.
+func encodeFields(fields []zap.Field) map[string]any {
+	enc := zapcore.NewMapObjectEncoder()
+
+	for _, f := range fields {
+		if f.Key == traceID || f.Key == spanID {
+			continue
+		}
+
+		f.AddTo(enc)
+	}
+
+	return enc.Fields
+}
+
+func spanAttribute(key string, v any) attribute.KeyValue {
+	switch x := v.(type) {
+	case string:
+		return attribute.String(key, x)
+	case bool:
+		return attribute.Bool(key, x)
+	case float64:
+		return attribute.Float64(key, x)
+	case float32:
+		return attribute.Float64(key, float64(x))
+	case time.Duration:
+		return attribute.String(key, x.String())
+	case time.Time:
+		return attribute.String(key, x.Format(time.RFC3339Nano))
+	}
+
+	if i, ok := toInt64(v); ok {
+		return attribute.Int64(key, i)
+	}
+
+	return attribute.String(key, stringify(v))
+}
+
+//nolint:gosec
+func toInt64(v any) (int64, bool) {
+	switch x := v.(type) {
+	case int:
+		return int64(x), true
+	case int64:
+		return x, true
+	case int32:
+		return int64(x), true
+	case int16:
+		return int64(x), true
+	case int8:
+		return int64(x), true
+	case uint:
+		return int64(x), true
+	case uint64:
+		return int64(x), true
+	case uint32:
+		return int64(x), true
+	case uint16:
+		return int64(x), true
+	case uint8:
+		return int64(x), true
+	case uintptr:
+		return int64(x), true
+	default:
+		return 0, false
+	}
+}
+
+func stringify(v any) string {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	if raw, err := json.Marshal(v); err == nil {
+		return string(raw)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// NewOTelCore returns a core emitting the entries as OTel log records of the
+// provider. The trace and span ids of the entries are sent as the record trace
+// context.
+func NewOTelCore(provider otellog.LoggerProvider, level zapcore.LevelEnabler) zapcore.Core {
+	return &otelCore{
+		logger: provider.Logger(OTelScope),
+		level:  level,
+	}
+}
+
+type otelCore struct {
+	logger otellog.Logger
+	level  zapcore.LevelEnabler
+	fields []zapcore.Field
+}
+
+func (c *otelCore) Enabled(l zapcore.Level) bool {
+	return c.level.Enabled(l)
+}
+
+func (c *otelCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+
+	return &otelCore{logger: c.logger, level: c.level, fields: all}
+}
+
+func (c *otelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	if !c.logger.Enabled(context.Background(), otellog.EnabledParameters{Severity: otelSeverity(ent.Level)}) {
+		return ce
+	}
+
+	return ce.AddCore(ent, c)
+}
+
+func (c *otelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := fields
+	if len(c.fields) > 0 {
+		all = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+		all = append(all, c.fields...)
+		all = append(all, fields...)
+	}
+
+	var r otellog.Record
+
+	r.SetTimestamp(ent.Time)
+	r.SetObservedTimestamp(time.Now())
+	r.SetSeverity(otelSeverity(ent.Level))
+	r.SetSeverityText(ent.Level.CapitalString())
+	r.SetBody(otellog.StringValue(ent.Message))
+
+	if ent.LoggerName != "" {
+		r.AddAttributes(otellog.String(loggerNameKey, ent.LoggerName))
+	}
+
+	if ent.Caller.Defined {
+		r.AddAttributes(otellog.String(callerKey, ent.Caller.TrimmedPath()))
+	}
+
+	if ent.Stack != "" {
+		r.AddAttributes(otellog.String(stackKey, ent.Stack))
+	}
+
+	encoded := encodeFields(all)
+	for _, k := range slices.Sorted(maps.Keys(encoded)) {
+		r.AddAttributes(otellog.KeyValue{Key: k, Value: otelValue(encoded[k])})
+	}
+
+	c.logger.Emit(traceContext(all), r)
+
+	return nil
+}
+
+func (c *otelCore) Sync() error {
+	return nil
+}
+
+// traceContext restores the span context from the trace and span id fields.
+func traceContext(fields []zapcore.Field) context.Context {
+	var cfg trace.SpanContextConfig
+
+	for _, f := range fields {
+		switch f.Key {
+		case traceID:
+			cfg.TraceID, _ = trace.TraceIDFromHex(f.String)
+		case spanID:
+			cfg.SpanID, _ = trace.SpanIDFromHex(f.String)
+		}
+	}
+
+	sc := trace.NewSpanContext(cfg)
+	if !sc.IsValid() {
+		return context.Background()
+	}
+
+	return trace.ContextWithRemoteSpanContext(context.Background(), sc)
+}
+
+func otelValue(v any) otellog.Value {
+	switch x := v.(type) {
+	case nil:
+		return otellog.Value{}
+	case string:
+		return otellog.StringValue(x)
+	case bool:
+		return otellog.BoolValue(x)
+	case float64:
+		return otellog.Float64Value(x)
+	case float32:
+		return otellog.Float64Value(float64(x))
+	case []byte:
+		return otellog.BytesValue(x)
+	case time.Duration:
+		return otellog.StringValue(x.String())
+	case time.Time:
+		return otellog.StringValue(x.Format(time.RFC3339Nano))
+	case map[string]any:
+		kvs := make([]otellog.KeyValue, 0, len(x))
+		for k, e := range x {
+			kvs = append(kvs, otellog.KeyValue{Key: k, Value: otelValue(e)})
+		}
+
+		return otellog.MapValue(kvs...)
+	case []any:
+		vs := make([]otellog.Value, 0, len(x))
+		for _, e := range x {
+			vs = append(vs, otelValue(e))
+		}
+
+		return otellog.SliceValue(vs...)
+	}
+
+	if i, ok := toInt64(v); ok {
+		return otellog.Int64Value(i)
+	}
+
+	return otellog.StringValue(stringify(v))
+}
+
+func otelSeverity(l zapcore.Level) otellog.Severity {
+	switch l {
+	case zapcore.DebugLevel:
+		return otellog.SeverityDebug
+	case zapcore.InfoLevel:
+		return otellog.SeverityInfo
+	case zapcore.WarnLevel:
+		return otellog.SeverityWarn
+	case zapcore.ErrorLevel:
+		return otellog.SeverityError
+	case zapcore.DPanicLevel:
+		return otellog.SeverityError2
+	case zapcore.PanicLevel:
+		return otellog.SeverityFatal
+	case zapcore.FatalLevel:
+		return otellog.SeverityFatal2
+	default:
+		return otellog.SeverityUndefined
+	}
+}
@@ -0,0 +1,146 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type memExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+
+	return nil
+}
+
+func (e *memExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func (e *memExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func recordAttrs(r sdklog.Record) map[string]otellog.Value {
+	res := make(map[string]otellog.Value)
+
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		res[kv.Key] = kv.Value
+
+		return true
+	})
+
+	return res
+}
+
+func TestSpanEvents(t *testing.T) {
+	var bs Buffer
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	l := newLevelsLog(&bs, zap.InfoLevel).WithSpanEvents()
+
+	ctx, span := tp.Tracer("test").Start(l.Inject(context.Background()), "op")
+
+	Info(ctx, "started")
+	Warn(ctx, "slow", Int("attempt", 2))
+	Err(ctx, "failed", Error(errors.New("eof")))
+	span.End()
+
+	require.Len(t, bs.Lines(), 3)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+
+	events := spans[0].Events()
+	require.Len(t, events, 2)
+
+	require.Equal(t, "slow", events[0].Name)
+	require.Contains(t, events[0].Attributes, attribute.String("log.severity", "WARN"))
+	require.Contains(t, events[0].Attributes, attribute.Int64("attempt", 2))
+
+	require.Equal(t, "failed", events[1].Name)
+	require.Contains(t, events[1].Attributes, attribute.String("error", "eof"))
+
+	for _, a := range events[1].Attributes {
+		require.NotEqual(t, attribute.Key("traceId"), a.Key)
+	}
+
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, "failed", spans[0].Status().Description)
+}
+
+func TestSpanEventsDisabled(t *testing.T) {
+	var bs Buffer
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	l := newLevelsLog(&bs, zap.InfoLevel)
+
+	ctx, span := tp.Tracer("test").Start(l.Inject(context.Background()), "op")
+
+	Err(ctx, "failed")
+	span.End()
+
+	require.Empty(t, sr.Ended()[0].Events())
+	require.Equal(t, codes.Unset, sr.Ended()[0].Status().Code)
+}
+
+func TestOTelCore(t *testing.T) {
+	exp := &memExporter{}
+	lp := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exp)))
+
+	tp := sdktrace.NewTracerProvider()
+
+	l := NewWithCore(NewOTelCore(lp, zap.InfoLevel)).Named("app")
+
+	ctx, span := tp.Tracer("test").Start(l.Inject(context.Background()), "op")
+
+	Debug(ctx, "hidden")
+	Warn(ctx, "slow", Int("attempt", 2), Any("meta", map[string]any{"host": "a"}))
+	span.End()
+
+	require.Len(t, exp.records, 1)
+
+	r := exp.records[0]
+	require.Equal(t, "slow", r.Body().AsString())
+	require.Equal(t, otellog.SeverityWarn, r.Severity())
+	require.Equal(t, "WARN", r.SeverityText())
+	require.Equal(t, span.SpanContext().TraceID(), r.TraceID())
+	require.Equal(t, span.SpanContext().SpanID(), r.SpanID())
+	require.Equal(t, OTelScope, r.InstrumentationScope().Name)
+
+	attrs := recordAttrs(r)
+	require.Equal(t, int64(2), attrs["attempt"].AsInt64())
+	require.Equal(t, "app", attrs["logger"].AsString())
+	require.Equal(t, otellog.KindMap, attrs["meta"].Kind())
+	require.NotContains(t, attrs, "traceId")
+	require.Contains(t, attrs, "caller")
+}
+
+func TestOTelSeverity(t *testing.T) {
+	require.Equal(t, otellog.SeverityError, otelSeverity(zapcore.ErrorLevel))
+	require.Equal(t, otellog.SeverityFatal, otelSeverity(zapcore.PanicLevel))
+}
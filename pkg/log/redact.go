@@ -0,0 +1,476 @@
+package log
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	DefaultMask = "***"
+
+	// PatternCardNumber matches payment card numbers with optional separators.
+	PatternCardNumber = `\b(?:\d[ -]?){12,18}\d\b`
+	// PatternEmail matches email addresses.
+	PatternEmail = `[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`
+
+	redactTag      = "log"
+	redactSecret   = "secret"
+	redactOmit     = "-"
+	redactMaxDepth = 32
+)
+
+// tagRedactor applies only the struct tags, it is used by the loggers without
+// a configured redactor.
+var tagRedactor = &Redactor{mask: DefaultMask}
+
+// taggedTypes caches whether the values of a type may hold tagged fields.
+var taggedTypes sync.Map
+
+// RedactionConfig describes how the fields of a logger are masked when the
+// entries are written.
+type RedactionConfig struct {
+	// Keys are masked wherever they appear, case-insensitive.
+	Keys []string `yaml:"keys"`
+	// Patterns are regular expressions masked inside string values.
+	Patterns []string `yaml:"patterns"`
+	// Allow switches to the allow-list mode: every scalar value of the reflected
+	// and object fields with a key not listed here is masked.
+	Allow []string `yaml:"allow"`
+	Mask  string   `yaml:"mask" default:"***"`
+}
+
+// Redactable is a value with its own redacted form, e.g. a payload rendered
+// as JSON and truncated after the redaction.
+type Redactable interface {
+	Redacted(key string, r *Redactor) any
+}
+
+// Redactor masks secrets in field values. Struct fields tagged `log:"secret"`
+// are always masked, fields tagged `log:"-"` are omitted, even by the loggers
+// without a redactor.
+type Redactor struct {
+	keys     map[string]struct{}
+	allow    map[string]struct{}
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+func NewRedactor(cfg RedactionConfig) (*Redactor, error) {
+	r := &Redactor{
+		keys: lowerSet(cfg.Keys),
+		mask: cfg.Mask,
+	}
+
+	if r.mask == "" {
+		r.mask = DefaultMask
+	}
+
+	if len(cfg.Allow) > 0 {
+		r.allow = lowerSet(cfg.Allow)
+	}
+
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("compile redaction pattern %q error: %w", p, err)
+		}
+
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// WithRedactor returns a logger masking the fields of its entries with r.
+func (l *Log) WithRedactor(r *Redactor) *Log {
+	newLog := l.clone()
+	newLog.l = l.l.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return newRedactCore(c, r)
+	}))
+	newLog.redactor = r
+
+	return newLog
+}
+
+// redactCore masks the fields when the entries are written, so the disabled
+// entries cost nothing.
+type redactCore struct {
+	zapcore.Core
+	r *Redactor
+}
+
+// newRedactCore wraps the core with r, nil applies only the struct tags.
+func newRedactCore(c zapcore.Core, r *Redactor) zapcore.Core {
+	if r == nil {
+		r = tagRedactor
+	}
+
+	return &redactCore{Core: c, r: r}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.fields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	writeChecked(c.Core, ent, c.r.fields(fields))
+
+	return nil
+}
+
+func lowerSet(ss []string) map[string]struct{} {
+	res := make(map[string]struct{}, len(ss))
+	for _, s := range ss {
+		res[strings.ToLower(s)] = struct{}{}
+	}
+
+	return res
+}
+
+// tagsOnly reports whether r applies nothing but the struct tags.
+func (r *Redactor) tagsOnly() bool {
+	return len(r.keys) == 0 && len(r.patterns) == 0 && r.allow == nil
+}
+
+func (r *Redactor) secretKey(key string) bool {
+	_, ok := r.keys[strings.ToLower(key)]
+
+	return ok
+}
+
+func (r *Redactor) allowed(key string) bool {
+	if r.allow == nil {
+		return true
+	}
+
+	_, ok := r.allow[strings.ToLower(key)]
+
+	return ok
+}
+
+// String masks the patterns found in s.
+func (r *Redactor) String(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, r.mask)
+	}
+
+	return s
+}
+
+// Value returns a copy of val safe to log: a tree of maps, slices and scalars
+// with the secrets masked.
+func (r *Redactor) Value(key string, val any) any {
+	if val == nil {
+		return nil
+	}
+
+	return r.value(key, reflect.ValueOf(val), 0)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	errorType         = reflect.TypeFor[error]()
+)
+
+//nolint:exhaustive,gocognit,cyclop
+func (r *Redactor) value(key string, v reflect.Value, depth int) any {
+	if r.secretKey(key) {
+		return r.mask
+	}
+
+	if depth > redactMaxDepth {
+		return r.mask
+	}
+
+	for {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil
+		}
+
+		if rd, ok := redactable(v); ok {
+			return rd.Redacted(key, r)
+		}
+
+		// opaque values are logged through their own representation
+		if isOpaque(v.Type()) && v.CanInterface() {
+			return r.scalar(key, opaque(v))
+		}
+
+		if v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface {
+			break
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return r.structValue(v, depth)
+	case reflect.Map:
+		res := make(map[string]any, v.Len())
+
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			res[k] = r.value(k, iter.Value(), depth+1)
+		}
+
+		return res
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return r.scalar(key, v.Interface())
+		}
+
+		res := make([]any, 0, v.Len())
+		for i := range v.Len() {
+			res = append(res, r.value(key, v.Index(i), depth+1))
+		}
+
+		return res
+	case reflect.String:
+		return r.scalar(key, v.String())
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	default:
+		return r.scalar(key, v.Interface())
+	}
+}
+
+func (r *Redactor) structValue(v reflect.Value, depth int) any {
+	t := v.Type()
+	res := make(map[string]any, t.NumField())
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		switch f.Tag.Get(redactTag) {
+		case redactOmit:
+			continue
+		case redactSecret:
+			res[fieldName(f)] = r.mask
+
+			continue
+		}
+
+		name := fieldName(f)
+		if name == "" {
+			continue
+		}
+
+		res[name] = r.value(name, v.Field(i), depth+1)
+	}
+
+	return res
+}
+
+// fieldName returns the JSON name of the field, empty for ignored fields.
+func fieldName(f reflect.StructField) string {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+
+	return f.Name
+}
+
+func redactable(v reflect.Value) (Redactable, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+
+	rd, ok := v.Interface().(Redactable)
+
+	return rd, ok
+}
+
+func isOpaque(t reflect.Type) bool {
+	return t.Implements(errorType) || t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+}
+
+func opaque(v reflect.Value) any {
+	switch x := v.Interface().(type) {
+	case error:
+		return x.Error()
+	case json.Marshaler:
+		raw, err := x.MarshalJSON()
+		if err != nil {
+			return err.Error()
+		}
+
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return s
+		}
+
+		return json.RawMessage(raw)
+	case encoding.TextMarshaler:
+		text, err := x.MarshalText()
+		if err != nil {
+			return err.Error()
+		}
+
+		return string(text)
+	default:
+		return v.Interface()
+	}
+}
+
+func (r *Redactor) scalar(key string, val any) any {
+	if !r.allowed(key) {
+		return r.mask
+	}
+
+	if s, ok := val.(string); ok {
+		return r.String(s)
+	}
+
+	return val
+}
+
+// fields returns the fields with the secrets masked, the slice is copied only
+// when a field changes.
+func (r *Redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	res := fields
+	copied := false
+
+	for i, f := range fields {
+		masked, ok := r.field(f)
+		if !ok {
+			continue
+		}
+
+		if !copied {
+			res = make([]zapcore.Field, len(fields))
+			copy(res, fields)
+			copied = true
+		}
+
+		res[i] = masked
+	}
+
+	return res
+}
+
+// field returns the masked field and true when the field has changed.
+//
+//nolint:exhaustive
+func (r *Redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if f.Type == zapcore.SkipType {
+		return f, false
+	}
+
+	if r.secretKey(f.Key) {
+		return zap.String(f.Key, r.mask), true
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		if s := r.String(f.String); s != f.String {
+			f.String = s
+
+			return f, true
+		}
+	case zapcore.ReflectType:
+		if rd, ok := f.Interface.(Redactable); ok {
+			return zap.Reflect(f.Key, rd.Redacted(f.Key, r)), true
+		}
+
+		if f.Interface == nil || r.tagsOnly() && !tagged(reflect.TypeOf(f.Interface)) {
+			return f, false
+		}
+
+		return zap.Reflect(f.Key, r.Value(f.Key, f.Interface)), true
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		if r.tagsOnly() {
+			return f, false
+		}
+
+		return r.encoded(f), true
+	}
+
+	return f, false
+}
+
+// encoded encodes the object or the array field into a map and returns the
+// redacted value.
+func (r *Redactor) encoded(f zapcore.Field) zapcore.Field {
+	enc := zapcore.NewMapObjectEncoder()
+
+	f.AddTo(enc)
+
+	if errVal, ok := enc.Fields[f.Key+"Error"]; ok {
+		return zap.Any(f.Key+"Error", errVal)
+	}
+
+	return zap.Reflect(f.Key, r.Value(f.Key, enc.Fields[f.Key]))
+}
+
+// tagged reports whether the values of t may have fields tagged log, the
+// interfaces are walked when logged.
+func tagged(t reflect.Type) bool {
+	if v, ok := taggedTypes.Load(t); ok {
+		return v.(bool) //nolint:forcetypeassert
+	}
+
+	res := taggedType(t, make(map[reflect.Type]struct{}))
+	taggedTypes.Store(t, res)
+
+	return res
+}
+
+//nolint:exhaustive
+func taggedType(t reflect.Type, seen map[reflect.Type]struct{}) bool {
+	if _, ok := seen[t]; ok {
+		return false
+	}
+
+	seen[t] = struct{}{}
+
+	if isOpaque(t) {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return taggedType(t.Elem(), seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			if f.Tag.Get(redactTag) != "" || taggedType(f.Type, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
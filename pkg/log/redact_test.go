@@ -0,0 +1,160 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type loginRequest struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Token    string `log:"secret"`
+	Internal string `log:"-"`
+	Comment  string
+	Cards    []string `json:"cards"`
+	When     time.Time
+	Meta     map[string]any
+	private  string
+}
+
+type marshaledUser struct {
+	name  string
+	token string
+}
+
+func (u marshaledUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.name)
+	enc.AddString("token", u.token)
+
+	return nil
+}
+
+// counted counts the encodings of the value.
+type counted struct {
+	n *int
+}
+
+func (c counted) MarshalJSON() ([]byte, error) {
+	*c.n++
+
+	return []byte(`"c"`), nil
+}
+
+func newRedactLog(t *testing.T, bs *Buffer, cfg *RedactionConfig) *Log {
+	t.Helper()
+
+	l := newLevelsLog(bs, zap.DebugLevel)
+
+	if cfg != nil {
+		r, err := NewRedactor(*cfg)
+		require.NoError(t, err)
+
+		l = l.WithRedactor(r)
+	}
+
+	return l
+}
+
+func logLine(t *testing.T, cfg *RedactionConfig, fields ...Field) string {
+	t.Helper()
+
+	var bs Buffer
+
+	l := newRedactLog(t, &bs, cfg)
+	Info(l.Inject(context.Background()), "msg", fields...)
+
+	lines := bs.Lines()
+	require.Len(t, lines, 1)
+
+	return lines[0]
+}
+
+func TestRedactReflect(t *testing.T) {
+	cfg := &RedactionConfig{
+		Keys:     []string{"password", "token"},
+		Patterns: []string{PatternCardNumber, PatternEmail},
+	}
+
+	req := loginRequest{
+		User:     "bob",
+		Password: "qwerty",
+		Token:    "abc",
+		Internal: "hidden",
+		Comment:  "mail me at bob@example.com",
+		Cards:    []string{"4111 1111 1111 1111"},
+		When:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Meta:     map[string]any{"token": "t", "n": 1},
+		private:  "p",
+	}
+
+	line := logLine(t, cfg, Reflect("request", &req))
+	require.Equal(t, `{"level":"INFO","@timestamp":"123","message":"msg","request":`+
+		`{"Comment":"mail me at ***","Meta":{"n":1,"token":"***"},"Token":"***",`+
+		`"When":"2024-01-02T03:04:05Z","cards":["***"],"password":"***","user":"bob"}}`, line)
+
+	line = logLine(t, cfg, Any("token", "abc"), Any("err", errors.New("e")), Object("user", marshaledUser{name: "bob", token: "t"}))
+	require.Equal(t, `{"level":"INFO","@timestamp":"123","message":"msg","token":"***","err":"e","user":{"name":"bob","token":"***"}}`, line)
+}
+
+func TestRedactAllowList(t *testing.T) {
+	cfg := &RedactionConfig{Allow: []string{"user"}, Mask: "x"}
+
+	line := logLine(t, cfg, Reflect("request", loginRequest{User: "bob", Password: "p", Comment: "c"}))
+	require.Contains(t, line, `"user":"bob"`)
+	require.Contains(t, line, `"password":"x"`)
+	require.Contains(t, line, `"Comment":"x"`)
+}
+
+func TestRedactDisabled(t *testing.T) {
+	line := logLine(t, nil, Reflect("request", struct{ Password string }{"p"}), String("token", "t"))
+	require.Contains(t, line, `"Password":"p"`)
+	require.Contains(t, line, `"token":"t"`)
+
+	line = logLine(t, nil, Reflect("request", loginRequest{User: "bob", Token: "abc", Internal: "i"}))
+	require.Contains(t, line, `"Token":"***"`, "the tags are applied without a redactor")
+	require.Contains(t, line, `"user":"bob"`)
+	require.NotContains(t, line, `"Internal"`)
+
+	_, err := NewRedactor(RedactionConfig{Patterns: []string{"("}})
+	require.Error(t, err)
+}
+
+func TestRedactScope(t *testing.T) {
+	var redacted, plain Buffer
+
+	cfg := &RedactionConfig{Keys: []string{"token"}}
+
+	ctx := newRedactLog(t, &redacted, cfg).Inject(context.Background())
+	Info(ctx, "msg", String("token", "abc"))
+
+	ctx = newRedactLog(t, &plain, nil).Inject(context.Background())
+	Info(ctx, "msg", String("token", "abc"))
+
+	require.Contains(t, redacted.Stripped(), `"token":"***"`)
+	require.Contains(t, plain.Stripped(), `"token":"abc"`, "the redactor is scoped to its logger")
+}
+
+func TestRedactOnWrite(t *testing.T) {
+	var (
+		bs Buffer
+		n  int
+	)
+
+	l := newRedactLog(t, &bs, &RedactionConfig{Keys: []string{"token"}})
+	l.Level().SetLevel(zap.InfoLevel)
+
+	ctx := l.Inject(context.Background())
+
+	Debug(ctx, "msg", Reflect("value", map[string]any{"c": counted{&n}}))
+	require.Zero(t, n, "the disabled entries are not redacted")
+
+	Info(ctx, "msg", Reflect("value", map[string]any{"c": counted{&n}}))
+	require.Equal(t, 1, n)
+	require.Contains(t, bs.Stripped(), `"value":{"c":"c"}`)
+}
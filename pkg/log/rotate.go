@@ -0,0 +1,381 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "20060102T150405.000"
+	compressSuffix   = ".gz"
+	megabyte         = 1024 * 1024
+
+	logFileMode = 0o644
+	logDirMode  = 0o755
+)
+
+// RotatingFile is a zapcore.WriteSyncer writing to a file that is rotated by
+// size and age. Rotated files are renamed to name-<timestamp>.ext, or
+// name-<timestamp>-<n>.ext when rotated twice within a millisecond, optionally
+// gzipped, and pruned by count and age in the background.
+type RotatingFile struct {
+	path         string
+	maxSize      int64
+	maxAge       time.Duration
+	maxBackups   int
+	compress     bool
+	rotateEvery  time.Duration
+	syncInterval time.Duration
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	opened  time.Time
+	millMu  sync.Mutex
+	milling sync.WaitGroup
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	syncing  sync.WaitGroup
+
+	// now is called under mu only
+	now func() time.Time
+}
+
+// NewRotatingFile opens the file described by the config, creating the
+// directories when needed.
+func NewRotatingFile(cfg FileConfig) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("log file path is empty")
+	}
+
+	r := &RotatingFile{
+		path:         cfg.Path,
+		maxSize:      int64(cfg.MaxSizeMB) * megabyte,
+		maxAge:       cfg.MaxAge,
+		maxBackups:   cfg.MaxBackups,
+		compress:     cfg.Compress,
+		rotateEvery:  cfg.RotateEvery,
+		syncInterval: cfg.SyncInterval,
+		stop:         make(chan struct{}),
+		now:          time.Now,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	if r.syncInterval > 0 {
+		r.syncing.Add(1)
+
+		go r.syncLoop()
+	}
+
+	return r, nil
+}
+
+// syncLoop syncs the file every sync interval until Close.
+func (r *RotatingFile) syncLoop() {
+	defer r.syncing.Done()
+
+	t := time.NewTicker(r.syncInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-t.C:
+			_ = r.Sync()
+		}
+	}
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+func (r *RotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	return r.file.Sync()
+}
+
+// Close syncs and closes the file and waits for the background compression and
+// pruning.
+func (r *RotatingFile) Close() error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	r.syncing.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+
+	if r.file != nil {
+		err = errors.Join(r.file.Sync(), r.file.Close())
+		r.file = nil
+	}
+
+	r.milling.Wait()
+
+	return err
+}
+
+// Rotate forces a rotation of the file.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rotate()
+}
+
+func (r *RotatingFile) shouldRotate(n int64) bool {
+	if r.maxSize > 0 && r.size > 0 && r.size+n > r.maxSize {
+		return true
+	}
+
+	return r.rotateEvery > 0 && r.now().Sub(r.opened) >= r.rotateEvery
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), logDirMode); err != nil {
+		return fmt.Errorf("create log dir error: %w", err)
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, logFileMode)
+	if err != nil {
+		return fmt.Errorf("open log file error: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+
+		return fmt.Errorf("stat log file error: %w", err)
+	}
+
+	r.file = f
+	r.size = info.Size()
+	r.opened = r.now()
+
+	return nil
+}
+
+func (r *RotatingFile) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return fmt.Errorf("close log file error: %w", err)
+		}
+
+		r.file = nil
+	}
+
+	now := r.now()
+
+	if _, err := os.Stat(r.path); err == nil {
+		if err = os.Rename(r.path, r.backupName(now)); err != nil {
+			return fmt.Errorf("rename log file error: %w", err)
+		}
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.milling.Add(1)
+
+	go func() {
+		defer r.milling.Done()
+
+		r.mill(now)
+	}()
+
+	return nil
+}
+
+func (r *RotatingFile) split() (dir, prefix, ext string) {
+	dir = filepath.Dir(r.path)
+	name := filepath.Base(r.path)
+	ext = filepath.Ext(name)
+	prefix = strings.TrimSuffix(name, ext) + "-"
+
+	return dir, prefix, ext
+}
+
+// backupName returns a free name of the file rotated at t, the rotations
+// within the same millisecond get a counter.
+func (r *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := r.split()
+	base := filepath.Join(dir, prefix+t.UTC().Format(backupTimeFormat))
+
+	name := base + ext
+	for n := 1; exists(name) || exists(name+compressSuffix); n++ {
+		name = base + "-" + strconv.Itoa(n) + ext
+	}
+
+	return name
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+
+	return err == nil
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+	n    int
+}
+
+// backups returns the rotated files, newest first.
+func (r *RotatingFile) backups() ([]backupFile, error) {
+	dir, prefix, ext := r.split()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]backupFile, 0, len(entries))
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		ts := strings.TrimPrefix(name, prefix)
+
+		switch {
+		case strings.HasSuffix(ts, ext+compressSuffix):
+			ts = strings.TrimSuffix(ts, ext+compressSuffix)
+		case strings.HasSuffix(ts, ext):
+			ts = strings.TrimSuffix(ts, ext)
+		default:
+			continue
+		}
+
+		var (
+			n       int
+			counter string
+			err     error
+		)
+
+		if ts, counter, _ = strings.Cut(ts, "-"); counter != "" {
+			if n, err = strconv.Atoi(counter); err != nil {
+				continue
+			}
+		}
+
+		t, err := time.Parse(backupTimeFormat, ts)
+		if err != nil {
+			continue
+		}
+
+		res = append(res, backupFile{path: filepath.Join(dir, name), time: t, n: n})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].time.Equal(res[j].time) {
+			return res[i].n > res[j].n
+		}
+
+		return res[i].time.After(res[j].time)
+	})
+
+	return res, nil
+}
+
+// mill prunes and compresses the rotated files, now is the time of the
+// rotation.
+func (r *RotatingFile) mill(now time.Time) {
+	r.millMu.Lock()
+	defer r.millMu.Unlock()
+
+	files, err := r.backups()
+	if err != nil {
+		return
+	}
+
+	cutoff := now.Add(-r.maxAge)
+
+	for i, f := range files {
+		if (r.maxBackups > 0 && i >= r.maxBackups) || (r.maxAge > 0 && f.time.Before(cutoff)) {
+			_ = os.Remove(f.path)
+
+			continue
+		}
+
+		if r.compress && !strings.HasSuffix(f.path, compressSuffix) {
+			_ = compressFile(f.path)
+		}
+	}
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, logFileMode)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		_ = os.Remove(path + compressSuffix)
+
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	r, err := NewRotatingFile(FileConfig{Path: path, MaxBackups: 2, Compress: true})
+	require.NoError(t, err)
+
+	r.maxSize = 10
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time {
+		now = now.Add(time.Second)
+
+		return now
+	}
+
+	for range 5 {
+		_, err = r.Write([]byte("0123456789"))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, r.Close())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	require.Len(t, names, 3, names)
+	require.Contains(t, names, "app.log")
+
+	for _, n := range names {
+		if n != "app.log" {
+			require.True(t, strings.HasPrefix(n, "app-"), n)
+			require.True(t, strings.HasSuffix(n, ".log.gz"), n)
+		}
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	old := filepath.Join(dir, "app-"+time.Now().Add(-48*time.Hour).UTC().Format(backupTimeFormat)+".log")
+	require.NoError(t, os.WriteFile(old, []byte("old"), 0o600))
+
+	r, err := NewRotatingFile(FileConfig{Path: path, MaxAge: 24 * time.Hour})
+	require.NoError(t, err)
+
+	_, err = r.Write([]byte("line"))
+	require.NoError(t, err)
+	require.NoError(t, r.Rotate())
+	require.NoError(t, r.Close())
+
+	_, err = os.Stat(old)
+	require.True(t, os.IsNotExist(err))
+
+	files, err := r.backups()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+func TestRotatingFileSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+
+	r, err := NewRotatingFile(FileConfig{Path: filepath.Join(dir, "app.log")})
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	for i := range 3 {
+		_, err = r.Write([]byte{byte('a' + i)})
+		require.NoError(t, err)
+		require.NoError(t, r.Rotate())
+	}
+
+	require.NoError(t, r.Close())
+
+	files, err := r.backups()
+	require.NoError(t, err)
+	require.Len(t, files, 3, "the backups of the same millisecond are kept")
+
+	// newest first
+	for i, want := range []string{"c", "b", "a"} {
+		data, err := os.ReadFile(files[i].path)
+		require.NoError(t, err)
+		require.Equal(t, want, string(data))
+	}
+}
+
+func TestRotatingFileSyncInterval(t *testing.T) {
+	r, err := NewRotatingFile(FileConfig{Path: filepath.Join(t.TempDir(), "app.log"), SyncInterval: time.Millisecond})
+	require.NoError(t, err)
+
+	_, err = r.Write([]byte("line"))
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+
+	require.NoError(t, r.Close())
+	require.NoError(t, r.Close())
+}
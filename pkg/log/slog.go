@@ -0,0 +1,243 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler is a slog.Handler writing records to the *Log found in the
+// record context, so the fields added with WithFields and the trace and span
+// ids are kept. Records without a logger in the context go to the fallback.
+type SlogHandler struct {
+	fallback *Log
+	// stack[0] holds the top level attributes, every WithGroup pushes a group
+	stack []slogGroup
+}
+
+type slogGroup struct {
+	name  string
+	attrs []slog.Attr
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler returns a handler, fallback may be nil to drop records
+// without a logger in the context.
+func NewSlogHandler(fallback *Log) *SlogHandler {
+	if fallback == nil {
+		fallback = Nop()
+	}
+
+	return &SlogHandler{
+		fallback: fallback,
+		stack:    []slogGroup{{}},
+	}
+}
+
+// SetSlogDefault installs a SlogHandler as the slog default handler. It also
+// redirects the standard log package.
+func SetSlogDefault(fallback *Log) {
+	slog.SetDefault(slog.New(NewSlogHandler(fallback)))
+}
+
+// Slog returns a slog logger writing to l when the context has no logger.
+func (l *Log) Slog() *slog.Logger {
+	return slog.New(NewSlogHandler(l))
+}
+
+func (h *SlogHandler) logger(ctx context.Context) *Log {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey).(*Log); ok {
+			return l
+		}
+	}
+
+	return h.fallback
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.logger(ctx).enabled(zapLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	l := h.logger(ctx)
+
+	ent := zapcore.Entry{
+		Level:      zapLevel(r.Level),
+		Time:       r.Time,
+		LoggerName: l.l.Name(),
+		Message:    r.Message,
+	}
+
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+
+	ce := l.l.Core().Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	record := make([]slog.Attr, 0, r.NumAttrs())
+
+	r.Attrs(func(a slog.Attr) bool {
+		record = append(record, a)
+
+		return true
+	})
+
+	fields := l.buildLoggerFields(ctx, h.fields(record)...)
+	ce.Write(fields...)
+	l.spanEvent(ctx, ent.Level, r.Message, fields)
+
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	nh := h.clone()
+	top := &nh.stack[len(nh.stack)-1]
+	top.attrs = append(top.attrs[:len(top.attrs):len(top.attrs)], attrs...)
+
+	return nh
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	nh := h.clone()
+	nh.stack = append(nh.stack, slogGroup{name: name})
+
+	return nh
+}
+
+func (h *SlogHandler) clone() *SlogHandler {
+	stack := make([]slogGroup, len(h.stack))
+	copy(stack, h.stack)
+
+	return &SlogHandler{fallback: h.fallback, stack: stack}
+}
+
+// fields nests the record attributes into the open groups and converts the
+// result into fields.
+func (h *SlogHandler) fields(record []slog.Attr) []Field {
+	attrs := record
+
+	for i := len(h.stack) - 1; i > 0; i-- {
+		g := h.stack[i]
+
+		inner := make([]slog.Attr, 0, len(g.attrs)+len(attrs))
+		inner = append(inner, g.attrs...)
+		inner = append(inner, attrs...)
+
+		attrs = []slog.Attr{{Key: g.name, Value: slog.GroupValue(inner...)}}
+	}
+
+	top := h.stack[0].attrs
+	all := make([]slog.Attr, 0, len(top)+len(attrs))
+	all = append(all, top...)
+	all = append(all, attrs...)
+
+	res := make([]Field, 0, len(all))
+	for _, a := range all {
+		res = appendAttr(res, a)
+	}
+
+	return res
+}
+
+func appendAttr(fields []Field, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		if len(group) == 0 {
+			return fields
+		}
+
+		if a.Key == "" {
+			for _, ga := range group {
+				fields = appendAttr(fields, ga)
+			}
+
+			return fields
+		}
+
+		return append(fields, Object(a.Key, slogObject(group)))
+	}
+
+	return append(fields, attrField(a))
+}
+
+func attrField(a slog.Attr) Field {
+	v := a.Value
+
+	switch v.Kind() {
+	case slog.KindString:
+		return String(a.Key, v.String())
+	case slog.KindInt64:
+		return Int64(a.Key, v.Int64())
+	case slog.KindUint64:
+		return Uint64(a.Key, v.Uint64())
+	case slog.KindFloat64:
+		return Float64(a.Key, v.Float64())
+	case slog.KindBool:
+		return Bool(a.Key, v.Bool())
+	case slog.KindDuration:
+		return Duration(a.Key, v.Duration())
+	case slog.KindTime:
+		return Time(a.Key, v.Time())
+	default:
+		if err, ok := v.Any().(error); ok {
+			return NamedError(a.Key, err)
+		}
+
+		return Any(a.Key, v.Any())
+	}
+}
+
+// slogObject encodes a slog group as a nested object.
+type slogObject []slog.Attr
+
+func (o slogObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	fields := make([]Field, 0, len(o))
+	for _, a := range o {
+		fields = appendAttr(fields, a)
+	}
+
+	for _, f := range fields {
+		f.field.AddTo(enc)
+	}
+
+	return nil
+}
+
+func zapLevel(l slog.Level) zapcore.Level {
+	switch {
+	case l < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case l < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case l < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/0wnperception/go-helpers/pkg/types"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var bs, fallback Buffer
+
+	l := newLevelsLog(&bs, zap.InfoLevel)
+	ctx := WithFields(l.Inject(context.Background()), String("request", "r1"))
+
+	logger := slog.New(NewSlogHandler(newLevelsLog(&fallback, zap.InfoLevel)))
+
+	logger.DebugContext(ctx, "hidden")
+	logger.InfoContext(ctx, "info", "count", 3, "dec", types.NewDecimal(12, -1))
+	logger.With("svc", "mqtt").WithGroup("conn").With("host", "h").
+		WarnContext(ctx, "grouped", slog.Group("retry", "n", 2), slog.Group("empty"))
+	logger.ErrorContext(ctx, "failed", "err", errors.New("boom"))
+	logger.Info("no context")
+
+	lines := bs.Lines()
+	require.Len(t, lines, 3)
+	require.Equal(t, `{"level":"INFO","@timestamp":"123","caller":"log/slog_test.go:23","message":"info","request":"r1","count":3,"dec":"1.2"}`, lines[0])
+	require.Equal(t, `{"level":"WARN","@timestamp":"123","caller":"log/slog_test.go:25","message":"grouped","request":"r1","svc":"mqtt","conn":{"host":"h","retry":{"n":2}}}`, lines[1])
+	require.Equal(t, `{"level":"ERROR","@timestamp":"123","caller":"log/slog_test.go:26","message":"failed","request":"r1","err":"boom"}`, lines[2])
+
+	require.Len(t, fallback.Lines(), 1)
+	require.Contains(t, fallback.Lines()[0], `"message":"no context"`)
+}
+
+func TestSlogLevels(t *testing.T) {
+	require.Equal(t, zap.DebugLevel, zapLevel(slog.LevelDebug))
+	require.Equal(t, zap.InfoLevel, zapLevel(slog.LevelInfo+1))
+	require.Equal(t, zap.WarnLevel, zapLevel(slog.LevelWarn))
+	require.Equal(t, zap.ErrorLevel, zapLevel(slog.LevelError+4))
+}
@@ -0,0 +1,488 @@
+// Package metrics is a small registry of counters, gauges and histograms
+// rendered in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+
+	labelSep = "\xff"
+
+	// ContentType is the content type of the text exposition format.
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var (
+	ErrDuplicateMetric = errors.New("duplicate metric")
+	ErrInvalidName     = errors.New("invalid metric name")
+
+	nameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+	// DefBuckets are the default histogram buckets in seconds.
+	DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+)
+
+// Collector is a metric family known to a Registry.
+type Collector interface {
+	describe() *metricDesc
+	write(w *bufio.Writer)
+}
+
+type metricDesc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *metricDesc) describe() *metricDesc {
+	return d
+}
+
+func (d *metricDesc) writeHeader(w *bufio.Writer) {
+	if d.help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	}
+
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.typ)
+}
+
+// Registry holds the collectors rendered by Write and Handler.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]Collector)}
+}
+
+// Register adds the collectors, the names must be valid and unique.
+func (r *Registry) Register(cs ...Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range cs {
+		d := c.describe()
+
+		if !nameRe.MatchString(d.name) {
+			return fmt.Errorf("%w: %q", ErrInvalidName, d.name)
+		}
+
+		for _, l := range d.labels {
+			if !nameRe.MatchString(l) || strings.Contains(l, ":") {
+				return fmt.Errorf("%w: label %q of %q", ErrInvalidName, l, d.name)
+			}
+		}
+
+		if _, ok := r.collectors[d.name]; ok {
+			return fmt.Errorf("%w: %q", ErrDuplicateMetric, d.name)
+		}
+
+		r.collectors[d.name] = c
+	}
+
+	return nil
+}
+
+// MustRegister is Register panicking on error.
+func (r *Registry) MustRegister(cs ...Collector) {
+	if err := r.Register(cs...); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes the collector with the name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.collectors, name)
+}
+
+// Write renders the metrics sorted by name in the text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+
+	cs := make([]Collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		cs = append(cs, c)
+	}
+
+	r.mu.RUnlock()
+
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].describe().name < cs[j].describe().name
+	})
+
+	bw := bufio.NewWriter(w)
+
+	for _, c := range cs {
+		c.describe().writeHeader(bw)
+		c.write(bw)
+	}
+
+	return bw.Flush()
+}
+
+// Handler serves the metrics, e.g. on /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", ContentType)
+
+		_ = r.Write(w)
+	})
+}
+
+// vec holds the children of a metric family by label values.
+type vec[T any] struct {
+	metricDesc
+
+	mu       sync.RWMutex
+	children map[string]*child[T]
+	newValue func() *T
+}
+
+type child[T any] struct {
+	values []string
+	value  *T
+}
+
+func newVec[T any](name, help, typ string, labels []string, newValue func() *T) vec[T] {
+	return vec[T]{
+		metricDesc: metricDesc{name: name, help: help, typ: typ, labels: labels},
+		children:   make(map[string]*child[T]),
+		newValue:   newValue,
+	}
+}
+
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metric %q: %d label values for %d labels", v.name, len(values), len(v.labels)))
+	}
+
+	key := strings.Join(values, labelSep)
+
+	v.mu.RLock()
+	c, ok := v.children[key]
+	v.mu.RUnlock()
+
+	if ok {
+		return c.value
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if c, ok = v.children[key]; !ok {
+		c = &child[T]{values: slices.Clone(values), value: v.newValue()}
+		v.children[key] = c
+	}
+
+	return c.value
+}
+
+// sorted returns the children sorted by label values.
+func (v *vec[T]) sorted() []*child[T] {
+	v.mu.RLock()
+
+	res := make([]*child[T], 0, len(v.children))
+	for _, c := range v.children {
+		res = append(res, c)
+	}
+
+	v.mu.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return slices.Compare(res[i].values, res[j].values) < 0
+	})
+
+	return res
+}
+
+// value is a float64 updated atomically.
+type value struct {
+	bits atomic.Uint64
+}
+
+func (v *value) Add(d float64) {
+	for {
+		old := v.bits.Load()
+		if v.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+d)) {
+			return
+		}
+	}
+}
+
+func (v *value) Set(f float64) {
+	v.bits.Store(math.Float64bits(f))
+}
+
+func (v *value) Value() float64 {
+	return math.Float64frombits(v.bits.Load())
+}
+
+// Counter is a value that only goes up.
+type Counter struct {
+	v value
+}
+
+func (c *Counter) Inc() {
+	c.v.Add(1)
+}
+
+// Add increases the counter, negative deltas are ignored.
+func (c *Counter) Add(d float64) {
+	if d > 0 {
+		c.v.Add(d)
+	}
+}
+
+func (c *Counter) Value() float64 {
+	return c.v.Value()
+}
+
+type CounterVec struct {
+	vec[Counter]
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{vec: newVec(name, help, typeCounter, labels, func() *Counter { return &Counter{} })}
+}
+
+// With returns the counter with the label values in the order of the labels.
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.with(values)
+}
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	for _, c := range v.sorted() {
+		writeSample(w, v.name, v.labels, c.values, "", "", c.value.Value())
+	}
+}
+
+// Gauge is a value that goes up and down.
+type Gauge struct {
+	v value
+}
+
+func (g *Gauge) Inc() {
+	g.v.Add(1)
+}
+
+func (g *Gauge) Dec() {
+	g.v.Add(-1)
+}
+
+func (g *Gauge) Add(d float64) {
+	g.v.Add(d)
+}
+
+func (g *Gauge) Set(f float64) {
+	g.v.Set(f)
+}
+
+func (g *Gauge) Value() float64 {
+	return g.v.Value()
+}
+
+type GaugeVec struct {
+	vec[Gauge]
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{vec: newVec(name, help, typeGauge, labels, func() *Gauge { return &Gauge{} })}
+}
+
+// With returns the gauge with the label values in the order of the labels.
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.with(values)
+}
+
+func (v *GaugeVec) write(w *bufio.Writer) {
+	for _, c := range v.sorted() {
+		writeSample(w, v.name, v.labels, c.values, "", "", c.value.Value())
+	}
+}
+
+// funcMetric is a counter or a gauge without labels read on every Write.
+type funcMetric struct {
+	metricDesc
+	f func() float64
+}
+
+// NewCounterFunc returns a counter reading its value from f.
+func NewCounterFunc(name, help string, f func() float64) Collector {
+	return &funcMetric{metricDesc: metricDesc{name: name, help: help, typ: typeCounter}, f: f}
+}
+
+// NewGaugeFunc returns a gauge reading its value from f.
+func NewGaugeFunc(name, help string, f func() float64) Collector {
+	return &funcMetric{metricDesc: metricDesc{name: name, help: help, typ: typeGauge}, f: f}
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	writeSample(w, m.name, nil, nil, "", "", m.f())
+}
+
+// Histogram counts observations in cumulative buckets.
+type Histogram struct {
+	upper []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upper, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+
+	h.count++
+	h.sum += v
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.count
+}
+
+type HistogramVec struct {
+	vec[Histogram]
+}
+
+// NewHistogramVec returns a histogram family with the bucket upper bounds,
+// DefBuckets when empty.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+
+	upper := slices.Clone(buckets)
+	slices.Sort(upper)
+	upper = slices.Compact(upper)
+
+	return &HistogramVec{vec: newVec(name, help, typeHistogram, labels, func() *Histogram {
+		return &Histogram{upper: upper, counts: make([]uint64, len(upper))}
+	})}
+}
+
+// With returns the histogram with the label values in the order of the labels.
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.with(values)
+}
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	for _, c := range v.sorted() {
+		h := c.value
+
+		h.mu.Lock()
+		counts := slices.Clone(h.counts)
+		count, sum := h.count, h.sum
+		h.mu.Unlock()
+
+		var cumulative uint64
+
+		for i, upper := range h.upper {
+			cumulative += counts[i]
+			writeSample(w, v.name+"_bucket", v.labels, c.values, "le", formatFloat(upper), float64(cumulative))
+		}
+
+		writeSample(w, v.name+"_bucket", v.labels, c.values, "le", "+Inf", float64(count))
+		writeSample(w, v.name+"_sum", v.labels, c.values, "", "", sum)
+		writeSample(w, v.name+"_count", v.labels, c.values, "", "", float64(count))
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, v float64) {
+	w.WriteString(name)
+
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+
+			writeLabel(w, l, values[i])
+		}
+
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+
+			writeLabel(w, extraLabel, extraValue)
+		}
+
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func writeLabel(w *bufio.Writer, name, value string) {
+	w.WriteString(name)
+	w.WriteString(`="`)
+	w.WriteString(labelEscaper.Replace(value))
+	w.WriteByte('"')
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	reg := NewRegistry()
+
+	requests := NewCounterVec("requests_total", "Requests.", "method", "code")
+	inFlight := NewGaugeVec("in_flight", "", "method")
+	latency := NewHistogramVec("latency_seconds", "Latency.\nSeconds.", []float64{1, 0.1}, "method")
+
+	reg.MustRegister(requests, inFlight, latency, NewGaugeFunc("up", "Up.", func() float64 { return 1 }))
+
+	requests.With("b", "OK").Inc()
+	requests.With("a", "OK").Add(2)
+	requests.With("a", "OK").Add(-1)
+	requests.With(`q"\`, "OK").Inc()
+	inFlight.With("a").Inc()
+	inFlight.With("a").Inc()
+	inFlight.With("a").Dec()
+	latency.With("a").Observe(0.05)
+	latency.With("a").Observe(0.5)
+	latency.With("a").Observe(5)
+
+	var sb strings.Builder
+	require.NoError(t, reg.Write(&sb))
+
+	require.Equal(t, `# TYPE in_flight gauge
+in_flight{method="a"} 1
+# HELP latency_seconds Latency.\nSeconds.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{method="a",le="0.1"} 1
+latency_seconds_bucket{method="a",le="1"} 2
+latency_seconds_bucket{method="a",le="+Inf"} 3
+latency_seconds_sum{method="a"} 5.55
+latency_seconds_count{method="a"} 3
+# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{method="a",code="OK"} 2
+requests_total{method="b",code="OK"} 1
+requests_total{method="q\"\\",code="OK"} 1
+# HELP up Up.
+# TYPE up gauge
+up 1
+`, sb.String())
+}
+
+func TestRegistryRegister(t *testing.T) {
+	reg := NewRegistry()
+
+	require.NoError(t, reg.Register(NewCounterVec("a_total", "")))
+	require.ErrorIs(t, reg.Register(NewGaugeVec("a_total", "")), ErrDuplicateMetric)
+	require.ErrorIs(t, reg.Register(NewGaugeVec("a-b", "")), ErrInvalidName)
+	require.ErrorIs(t, reg.Register(NewGaugeVec("b", "", "x:y")), ErrInvalidName)
+
+	reg.Unregister("a_total")
+	require.NoError(t, reg.Register(NewGaugeVec("a_total", "")))
+
+	require.Panics(t, func() {
+		NewCounterVec("c_total", "", "a", "b").With("a")
+	})
+}
+
+func TestRegistryHandler(t *testing.T) {
+	reg := NewRegistry()
+	reg.MustRegister(NewCounterFunc("calls_total", "", func() float64 { return 3 }))
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	require.Equal(t, "# TYPE calls_total counter\ncalls_total 3\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -13,7 +13,7 @@ type MqttConfig struct {
 	ConnectionType    string
 	ClientId          string
 	UserName          string
-	Password          string
+	Password          string `secret:"true"`
 	PingTimeout       time.Duration
 	ReconnectAttempts int
 	ReconnectTimeout  time.Duration
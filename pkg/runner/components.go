@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0wnperception/go-helpers/pkg/depsgraph"
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+var (
+	ErrDuplicateComponent = errors.New("duplicate component")
+	ErrUnknownDependency  = errors.New("unknown component dependency")
+	ErrComponentBoot      = errors.New("component boot failed")
+)
+
+// Component is a part of the application with an explicit lifecycle. Init and
+// Start are called in dependency order, Start must return once the component
+// is running. Stop is called in reverse order for every component whose Init
+// succeeded, even if its Start failed.
+type Component interface {
+	// Name identifies the component for dependencies and logs.
+	Name() string
+	// Dependencies returns the names of the components that must be started first.
+	Dependencies() []string
+	Init(ctx context.Context) error
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+type componentKey string
+
+type componentNode struct {
+	c       Component
+	started *[]Component
+}
+
+func (n *componentNode) DataType() any {
+	return componentKey(n.c.Name())
+}
+
+func (n *componentNode) Dependencies() []any {
+	deps := n.c.Dependencies()
+	keys := make([]any, 0, len(deps))
+
+	for _, d := range deps {
+		keys = append(keys, componentKey(d))
+	}
+
+	return keys
+}
+
+func (n *componentNode) Execute(ctx context.Context) error {
+	ctx = log.WithFields(ctx, log.String("component", n.c.Name()))
+
+	if err := n.c.Init(ctx); err != nil {
+		return fmt.Errorf("init error: %w", err)
+	}
+
+	// a failed Start may leave the resources acquired by Init
+	*n.started = append(*n.started, n.c)
+
+	if err := n.c.Start(ctx); err != nil {
+		return fmt.Errorf("start error: %w", err)
+	}
+
+	log.Info(ctx, "component is started")
+
+	return nil
+}
+
+// bootComponents starts the components in dependency order. On failure the
+// initialized components are left to the shutdown, which stops them in reverse
+// order.
+func bootComponents(cfg *Config) error {
+	if len(cfg.components) == 0 {
+		return nil
+	}
+
+	graph, err := componentsGraph(cfg)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrComponentBoot, err)
+	}
+
+	if err = graph.ExecuteAll(cfg.ctx); err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("%w: %w", ErrComponentBoot, err)
+	log.Err(cfg.ctx, "components boot failed, rolling back", log.Error(err))
+
+	return err
+}
+
+func componentsGraph(cfg *Config) (*depsgraph.Graph, error) {
+	names := make(map[string]struct{}, len(cfg.components))
+
+	for _, c := range cfg.components {
+		if _, ok := names[c.Name()]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateComponent, c.Name())
+		}
+
+		names[c.Name()] = struct{}{}
+	}
+
+	graph := depsgraph.NewGraph()
+
+	for _, c := range cfg.components {
+		for _, d := range c.Dependencies() {
+			if _, ok := names[d]; !ok {
+				return nil, fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, c.Name(), d)
+			}
+		}
+
+		graph.AddNode(&componentNode{c: c, started: &cfg.startedComponents})
+	}
+
+	return graph, nil
+}
+
+// stopComponents stops the initialized components in reverse order.
+func stopComponents(ctx context.Context, cfg *Config) []HookResult {
+	results := make([]HookResult, 0, len(cfg.startedComponents))
+
+	for i := len(cfg.startedComponents) - 1; i >= 0; i-- {
+		c := cfg.startedComponents[i]
+
+		r := runHook(log.WithFields(ctx, log.String("component", c.Name())), c.Name(), cfg.hookTimeout, c.Stop)
+		if r.Err != nil {
+			log.Err(ctx, "component stop failed",
+				log.String("component", r.Name),
+				log.Duration("duration", r.Duration),
+				log.Error(r.Err))
+		} else {
+			log.Debug(ctx, "component is stopped",
+				log.String("component", r.Name),
+				log.Duration("duration", r.Duration))
+		}
+
+		results = append(results, r)
+	}
+
+	cfg.startedComponents = nil
+
+	return results
+}
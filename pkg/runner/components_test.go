@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type events struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (e *events) add(s string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.list = append(e.list, s)
+}
+
+type testComponent struct {
+	name     string
+	deps     []string
+	startErr error
+	events   *events
+}
+
+func (c *testComponent) Name() string           { return c.name }
+func (c *testComponent) Dependencies() []string { return c.deps }
+
+func (c *testComponent) Init(context.Context) error {
+	c.events.add("init " + c.name)
+
+	return nil
+}
+
+func (c *testComponent) Start(context.Context) error {
+	if c.startErr != nil {
+		return c.startErr
+	}
+
+	c.events.add("start " + c.name)
+
+	return nil
+}
+
+func (c *testComponent) Stop(context.Context) error {
+	c.events.add("stop " + c.name)
+
+	return nil
+}
+
+func TestComponentsOrder(t *testing.T) {
+	ev := &events{}
+
+	err := Run("test",
+		WithWaitFunc(noWait),
+		WithComponents(
+			&testComponent{name: "grpc", deps: []string{"mqtt", "journal"}, events: ev},
+			&testComponent{name: "journal", deps: []string{"mqtt"}, events: ev},
+			&testComponent{name: "mqtt", events: ev},
+		),
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"init mqtt", "start mqtt",
+		"init journal", "start journal",
+		"init grpc", "start grpc",
+		"stop grpc", "stop journal", "stop mqtt",
+	}, ev.list)
+}
+
+func TestComponentsRollback(t *testing.T) {
+	ev := &events{}
+	errStart := errors.New("start failed")
+	started := false
+
+	probes, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := probes.Addr().String()
+	require.NoError(t, probes.Close())
+
+	err = Run("test",
+		WithWaitFunc(noWait),
+		WithProbeServer(addr),
+		WithShutdownTimeout(time.Second),
+		WithShutdownHook("hook", 0, func(context.Context) error {
+			ev.add("hook")
+
+			return nil
+		}),
+		WithServices(func(context.Context) { started = true }),
+		WithComponents(
+			&testComponent{name: "mqtt", events: ev},
+			&testComponent{name: "journal", deps: []string{"mqtt"}, events: ev},
+			&testComponent{name: "grpc", deps: []string{"journal"}, startErr: errStart, events: ev},
+		),
+	)
+
+	require.ErrorIs(t, err, ErrComponentBoot)
+	require.ErrorIs(t, err, errStart)
+	require.False(t, started)
+	require.Equal(t, []string{
+		"init mqtt", "start mqtt",
+		"init journal", "start journal",
+		"init grpc",
+		"hook", "stop grpc", "stop journal", "stop mqtt",
+	}, ev.list, "the boot failure runs the shutdown and stops the component failed to start")
+
+	// the probe server is stopped
+	probes, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	require.NoError(t, probes.Close())
+}
+
+func TestComponentsInvalid(t *testing.T) {
+	ev := &events{}
+
+	err := Run("test",
+		WithWaitFunc(noWait),
+		WithComponents(&testComponent{name: "grpc", deps: []string{"mqtt"}, events: ev}),
+	)
+	require.ErrorIs(t, err, ErrUnknownDependency)
+
+	err = Run("test",
+		WithWaitFunc(noWait),
+		WithComponents(&testComponent{name: "a", events: ev}, &testComponent{name: "a", events: ev}),
+	)
+	require.ErrorIs(t, err, ErrDuplicateComponent)
+	require.Empty(t, ev.list)
+}
@@ -3,6 +3,7 @@ package runner
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 type ServiceFunc func(ctx context.Context)
@@ -10,29 +11,53 @@ type WaitFunc func()
 
 type Config struct {
 	services      []ServiceFunc
+	supervised    []supervisedService
+	components    []Component
 	waitFunc      WaitFunc
-	shutdownHooks []ServiceFunc
+	shutdownHooks []shutdownHook
+	reloadHooks   []ReloadFunc
 
-	ctx    context.Context
-	cancel context.CancelFunc
-	wg     *sync.WaitGroup
+	shutdownTimeout time.Duration
+	hookTimeout     time.Duration
+
+	startedComponents []Component
+
+	probes      *Probes
+	probeAddr   string
+	probeServer *probeServer
+	drainDelay  time.Duration
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      *sync.WaitGroup
+	aborted chan error
 }
 
 func NewAppConfig(ctx context.Context, opts ...Option) *Config {
-	ctx, cancel := context.WithCancel(ctx)
+	probes := newProbes()
+
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, probesKey{}, probes))
 	cfg := &Config{
-		ctx:    ctx,
-		cancel: cancel,
-		wg:     &sync.WaitGroup{},
+		ctx:             ctx,
+		cancel:          cancel,
+		wg:              &sync.WaitGroup{},
+		aborted:         make(chan error, 1),
+		probes:          probes,
+		shutdownTimeout: DefaultShutdownTimeout,
 	}
 
 	for _, ops := range opts {
 		ops(cfg)
 	}
 
-	if cfg.waitFunc == nil {
-		cfg.waitFunc = DefaultWaitFunc
-	}
-
 	return cfg
 }
+
+// abort stops waiting for the termination signal and starts the shutdown.
+// Only the first reason is kept.
+func (cfg *Config) abort(err error) {
+	select {
+	case cfg.aborted <- err:
+	default:
+	}
+}
@@ -1,5 +1,11 @@
 package runner
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
 type Option func(cfg *Config)
 
 func WithServices(fs ...ServiceFunc) Option {
@@ -8,14 +14,114 @@ func WithServices(fs ...ServiceFunc) Option {
 	}
 }
 
+// WithComponents registers components started in dependency order before the
+// services and stopped in reverse order after them.
+func WithComponents(cs ...Component) Option {
+	return func(cfg *Config) {
+		for _, c := range cs {
+			if c != nil {
+				cfg.components = append(cfg.components, c)
+			}
+		}
+	}
+}
+
+// WithSupervisedService registers a service that is restarted according to
+// the supervision spec and recovered from panics.
+func WithSupervisedService(name string, f SupervisedFunc, spec Supervision) Option {
+	return func(cfg *Config) {
+		cfg.supervised = append(cfg.supervised, supervisedService{
+			name: name,
+			f:    f,
+			spec: spec,
+		})
+	}
+}
+
+// WithShutdownHooks registers hooks that can not fail. They are named by their
+// registration index in the shutdown summary.
 func WithShutdownHooks(fs ...ServiceFunc) Option {
 	return func(cfg *Config) {
-		cfg.shutdownHooks = append(cfg.shutdownHooks, fs...)
+		for _, f := range fs {
+			if f == nil {
+				continue
+			}
+
+			cfg.shutdownHooks = append(cfg.shutdownHooks, shutdownHook{
+				name: fmt.Sprintf("hook-%d", len(cfg.shutdownHooks)),
+				f: func(ctx context.Context) error {
+					f(ctx)
+
+					return nil
+				},
+			})
+		}
+	}
+}
+
+// WithShutdownHook registers a named hook. A zero timeout means the default
+// hook timeout set by WithHookTimeout.
+func WithShutdownHook(name string, timeout time.Duration, f HookFunc) Option {
+	return func(cfg *Config) {
+		cfg.shutdownHooks = append(cfg.shutdownHooks, shutdownHook{
+			name:    name,
+			timeout: timeout,
+			f:       f,
+		})
 	}
 }
 
+// WithShutdownTimeout sets the deadline for the whole shutdown sequence.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.shutdownTimeout = d
+	}
+}
+
+// WithHookTimeout sets the default deadline for hooks registered without one.
+func WithHookTimeout(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.hookTimeout = d
+	}
+}
+
+// WithProbeServer serves the liveness, health and readiness endpoints on addr.
+// The application becomes ready when every service has called ReportStarted.
+func WithProbeServer(addr string) Option {
+	return func(cfg *Config) {
+		cfg.probeAddr = addr
+	}
+}
+
+// WithHealthCheck registers a named health check, see Probes.AddCheck.
+func WithHealthCheck(name string, timeout, cacheTTL time.Duration, check HealthCheck) Option {
+	return func(cfg *Config) {
+		cfg.probes.AddCheck(name, timeout, cacheTTL, check)
+	}
+}
+
+// WithReadinessDrain sets the delay between reporting not ready and running
+// the shutdown hooks, so that load balancers stop sending traffic.
+func WithReadinessDrain(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.drainDelay = d
+	}
+}
+
+// WithWaitFunc replaces the signal handling of the runner: Run stops when f
+// returns. The reload hooks are not run with a wait function, Run logs a
+// warning about them.
 func WithWaitFunc(f WaitFunc) Option {
 	return func(cfg *Config) {
 		cfg.waitFunc = f
 	}
 }
+
+// WithSignalWait restores the signal handling of the runner replaced by
+// WithWaitFunc: Run stops on a termination signal, runs the reload hooks on
+// SIGHUP and exits on a second termination signal. It is the default.
+func WithSignalWait() Option {
+	return func(cfg *Config) {
+		cfg.waitFunc = nil
+	}
+}
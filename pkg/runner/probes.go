@@ -0,0 +1,323 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+const (
+	LivenessPath  = "/livez"
+	HealthPath    = "/healthz"
+	ReadinessPath = "/readyz"
+
+	probesStepName = "probes"
+
+	statusOK    = "ok"
+	statusError = "error"
+
+	probeReadHeaderTimeout = 5 * time.Second
+)
+
+var (
+	ErrNotReady           = errors.New("application is not ready")
+	ErrHealthCheckTimeout = errors.New("health check timeout")
+	ErrHealthCheckPanic   = errors.New("health check panic")
+)
+
+// HealthCheck reports the health of a dependency. Nil means healthy.
+type HealthCheck func(ctx context.Context) error
+
+type probesKey struct{}
+
+type startedKey struct{}
+
+type healthCheck struct {
+	name    string
+	timeout time.Duration
+	ttl     time.Duration
+	check   HealthCheck
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+// Probes keeps the registered health checks and the readiness state of the
+// application.
+type Probes struct {
+	mu     sync.RWMutex
+	checks []*healthCheck
+
+	pending  atomic.Int32
+	ready    atomic.Bool
+	stopping atomic.Bool
+}
+
+func newProbes() *Probes {
+	return &Probes{}
+}
+
+// ProbesFromContext returns the probes of the application the context belongs to.
+func ProbesFromContext(ctx context.Context) (*Probes, bool) {
+	p, ok := ctx.Value(probesKey{}).(*Probes)
+
+	return p, ok
+}
+
+// AddHealthCheck registers a named check in the probes found in the context.
+// See Probes.AddCheck.
+func AddHealthCheck(ctx context.Context, name string, timeout, cacheTTL time.Duration, check HealthCheck) {
+	if p, ok := ProbesFromContext(ctx); ok {
+		p.AddCheck(name, timeout, cacheTTL, check)
+	}
+}
+
+// ReportStarted marks the service owning the context as started. The
+// application becomes ready when all services have reported.
+func ReportStarted(ctx context.Context) {
+	if f, ok := ctx.Value(startedKey{}).(func()); ok {
+		f()
+	}
+}
+
+// AddCheck registers a named check. A positive timeout bounds every run of the
+// check, a positive cacheTTL reuses the last result while it is fresh.
+func (p *Probes) AddCheck(name string, timeout, cacheTTL time.Duration, check HealthCheck) {
+	if check == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.checks = append(p.checks, &healthCheck{
+		name:    name,
+		timeout: timeout,
+		ttl:     cacheTTL,
+		check:   check,
+	})
+}
+
+// Ready reports whether all services have started and the shutdown has not begun.
+func (p *Probes) Ready() bool {
+	return p.ready.Load()
+}
+
+// Check runs all health checks concurrently and returns their results by name.
+func (p *Probes) Check(ctx context.Context) map[string]error {
+	p.mu.RLock()
+	checks := make([]*healthCheck, len(p.checks))
+	copy(checks, p.checks)
+	p.mu.RUnlock()
+
+	errs := make([]error, len(checks))
+
+	var wg sync.WaitGroup
+
+	for i, c := range checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs[i] = c.run(ctx)
+		}()
+	}
+
+	wg.Wait()
+
+	results := make(map[string]error, len(checks))
+	for i, c := range checks {
+		results[c.name] = errs[i]
+	}
+
+	return results
+}
+
+// Handler serves the liveness, health and readiness endpoints.
+func (p *Probes) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(LivenessPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeProbe(w, nil, nil)
+	})
+
+	mux.HandleFunc(HealthPath, func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, nil, p.Check(r.Context()))
+	})
+
+	mux.HandleFunc(ReadinessPath, func(w http.ResponseWriter, r *http.Request) {
+		if !p.Ready() {
+			writeProbe(w, ErrNotReady, nil)
+
+			return
+		}
+
+		writeProbe(w, nil, p.Check(r.Context()))
+	})
+
+	return mux
+}
+
+// expect adds the number of services that have to report before the
+// application becomes ready.
+func (p *Probes) expect(n int) {
+	p.pending.Add(int32(n)) //nolint:gosec
+	p.updateReady()
+}
+
+func (p *Probes) started() {
+	p.pending.Add(-1)
+	p.updateReady()
+}
+
+func (p *Probes) updateReady() {
+	p.ready.Store(p.pending.Load() <= 0 && !p.stopping.Load())
+}
+
+// drain marks the application as not ready, it stays so until exit.
+func (p *Probes) drain() {
+	p.stopping.Store(true)
+	p.ready.Store(false)
+}
+
+// withStarted returns a context whose ReportStarted counts once for the probes.
+func (p *Probes) withStarted(ctx context.Context) context.Context {
+	var once sync.Once
+
+	return context.WithValue(ctx, startedKey{}, func() {
+		once.Do(p.started)
+	})
+}
+
+func (c *healthCheck) run(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl > 0 && !c.checked.IsZero() && time.Since(c.checked) < c.ttl {
+		return c.err
+	}
+
+	var cancel context.CancelFunc
+
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- fmt.Errorf("%w: %v", ErrHealthCheckPanic, rec)
+			}
+		}()
+
+		done <- c.check(ctx)
+	}()
+
+	select {
+	case c.err = <-done:
+	case <-ctx.Done():
+		c.err = fmt.Errorf("%w: %w", ErrHealthCheckTimeout, ctx.Err())
+	}
+
+	c.checked = time.Now()
+
+	return c.err
+}
+
+type probeResponse struct {
+	Status string            `json:"status"`
+	Error  string            `json:"error,omitempty"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func writeProbe(w http.ResponseWriter, err error, checks map[string]error) {
+	resp := probeResponse{Status: statusOK}
+
+	if err != nil {
+		resp.Status = statusError
+		resp.Error = err.Error()
+	}
+
+	if len(checks) > 0 {
+		resp.Checks = make(map[string]string, len(checks))
+
+		for name, e := range checks {
+			if e != nil {
+				resp.Status = statusError
+				resp.Checks[name] = e.Error()
+			} else {
+				resp.Checks[name] = statusOK
+			}
+		}
+	}
+
+	code := http.StatusOK
+	if resp.Status != statusOK {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+type probeServer struct {
+	server *http.Server
+	done   chan struct{}
+}
+
+func startProbeServer(ctx context.Context, addr string, p *Probes) (*probeServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("probe server listen error: %w", err)
+	}
+
+	ps := &probeServer{
+		server: &http.Server{
+			Handler:           p.Handler(),
+			ReadHeaderTimeout: probeReadHeaderTimeout,
+		},
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(ps.done)
+
+		if err := ps.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Err(ctx, "probe server error", log.Error(err))
+		}
+	}()
+
+	log.Info(ctx, "probe server is listening", log.String("addr", listener.Addr().String()))
+
+	return ps, nil
+}
+
+func (ps *probeServer) stop(ctx context.Context) HookResult {
+	started := time.Now()
+
+	err := ps.server.Shutdown(ctx)
+	if err == nil {
+		<-ps.done
+	} else if ctx.Err() != nil {
+		err = fmt.Errorf("%w: %w", ErrShutdownTimeout, err)
+	}
+
+	return HookResult{Name: probesStepName, Err: err, Duration: time.Since(started)}
+}
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, h http.Handler, path string) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return rec.Code
+}
+
+func TestProbesReadiness(t *testing.T) {
+	p := newProbes()
+	h := p.Handler()
+
+	p.expect(2)
+
+	first := p.withStarted(context.Background())
+	second := p.withStarted(context.Background())
+
+	require.Equal(t, http.StatusOK, probe(t, h, LivenessPath))
+	require.Equal(t, http.StatusServiceUnavailable, probe(t, h, ReadinessPath))
+
+	ReportStarted(first)
+	ReportStarted(first)
+	require.False(t, p.Ready())
+
+	ReportStarted(second)
+	require.True(t, p.Ready())
+	require.Equal(t, http.StatusOK, probe(t, h, ReadinessPath))
+
+	p.drain()
+	require.Equal(t, http.StatusServiceUnavailable, probe(t, h, ReadinessPath))
+	require.Equal(t, http.StatusOK, probe(t, h, LivenessPath))
+}
+
+func TestProbesHealthChecks(t *testing.T) {
+	var calls atomic.Int32
+
+	p := newProbes()
+	h := p.Handler()
+
+	p.AddCheck("cached", 0, time.Minute, func(context.Context) error {
+		calls.Add(1)
+
+		return nil
+	})
+
+	require.Equal(t, http.StatusOK, probe(t, h, HealthPath))
+	require.Equal(t, http.StatusOK, probe(t, h, HealthPath))
+	require.Equal(t, int32(1), calls.Load())
+
+	p.AddCheck("slow", 10*time.Millisecond, 0, func(context.Context) error {
+		<-time.After(time.Minute)
+
+		return nil
+	})
+
+	res := p.Check(context.Background())
+	require.NoError(t, res["cached"])
+	require.ErrorIs(t, res["slow"], ErrHealthCheckTimeout)
+	require.Equal(t, http.StatusServiceUnavailable, probe(t, h, HealthPath))
+}
+
+func TestRunProbes(t *testing.T) {
+	var ready atomic.Bool
+
+	found := make(chan bool, 1)
+
+	err := Run("test",
+		WithProbeServer("127.0.0.1:0"),
+		WithWaitFunc(func() { time.Sleep(20 * time.Millisecond) }),
+		WithServices(func(ctx context.Context) {
+			ReportStarted(ctx)
+
+			p, ok := ProbesFromContext(ctx)
+			found <- ok
+
+			<-ctx.Done()
+
+			if ok {
+				ready.Store(p.Ready())
+			}
+		}),
+		WithHealthCheck("failing", 0, 0, func(context.Context) error {
+			return errors.New("unhealthy")
+		}),
+	)
+
+	require.NoError(t, err)
+	require.True(t, <-found)
+	require.False(t, ready.Load())
+}
+
+func TestRunProbesListenError(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer busy.Close()
+
+	err = Run("test",
+		WithProbeServer(busy.Addr().String()),
+		WithWaitFunc(noWait),
+	)
+	require.ErrorContains(t, err, "probe server listen error")
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0wnperception/go-helpers/pkg/config"
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+// ReloadFunc is called on every SIGHUP.
+type ReloadFunc func(ctx context.Context) error
+
+// ReloadSubscriber receives a freshly read and validated configuration.
+type ReloadSubscriber[T any] func(ctx context.Context, cfg *T) error
+
+// WithReloadHooks re-reads T through the reader on every SIGHUP, validates it
+// and dispatches it to the subscribers. A configuration that fails to read or
+// validate is not dispatched. The reader must read from a file, an io.Reader
+// can be consumed only once.
+func WithReloadHooks[T any](reader config.Reader, validate func(*T) error, subscribers ...ReloadSubscriber[T]) Option {
+	return WithReloadFuncs(func(ctx context.Context) error {
+		v := new(T)
+
+		if err := reader.Read(v); err != nil {
+			return fmt.Errorf("reload config error: %w", err)
+		}
+
+		if validate != nil {
+			if err := validate(v); err != nil {
+				return fmt.Errorf("validate config error: %w", err)
+			}
+		}
+
+		for _, s := range subscribers {
+			if s == nil {
+				continue
+			}
+
+			if err := s(ctx, v); err != nil {
+				log.Err(ctx, "config subscriber failed", log.Error(err))
+			}
+		}
+
+		return nil
+	})
+}
+
+// WithReloadFuncs registers functions called in registration order on every SIGHUP.
+func WithReloadFuncs(fs ...ReloadFunc) Option {
+	return func(cfg *Config) {
+		for _, f := range fs {
+			if f != nil {
+				cfg.reloadHooks = append(cfg.reloadHooks, f)
+			}
+		}
+	}
+}
+
+func reload(cfg *Config) {
+	log.Info(cfg.ctx, "Reloading configuration")
+
+	for _, f := range cfg.reloadHooks {
+		if err := runReload(cfg.ctx, f); err != nil {
+			log.Err(cfg.ctx, "reload failed", log.Error(err))
+		}
+	}
+}
+
+func runReload(ctx context.Context, f ReloadFunc) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("reload panic: %v", rec)
+		}
+	}()
+
+	return f(ctx)
+}
@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0wnperception/go-helpers/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+type reloadConfig struct {
+	Level string `yaml:"level"`
+}
+
+func TestReloadHooks(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+
+	require.NoError(t, os.WriteFile(file, []byte("level: info\n"), 0o600))
+
+	var got []string
+
+	validate := func(c *reloadConfig) error {
+		if c.Level == "" {
+			return errors.New("empty level")
+		}
+
+		return nil
+	}
+
+	cfg := NewAppConfig(context.Background(),
+		WithReloadHooks(config.New(config.WithConfigFile(file)), validate,
+			func(_ context.Context, c *reloadConfig) error {
+				got = append(got, c.Level)
+
+				return nil
+			}),
+	)
+
+	reload(cfg)
+
+	require.NoError(t, os.WriteFile(file, []byte("level: \"\"\n"), 0o600))
+	reload(cfg)
+
+	require.NoError(t, os.WriteFile(file, []byte("level: debug\n"), 0o600))
+	reload(cfg)
+
+	require.Equal(t, []string{"info", "debug"}, got)
+}
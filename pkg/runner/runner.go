@@ -2,11 +2,20 @@ package runner
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/0wnperception/go-helpers/pkg/log"
 )
 
-func Run(appName string, opts ...Option) {
+// Run boots the components in dependency order, starts the services and
+// blocks until the wait function returns or a critical supervised service
+// fails. Without a wait function it waits for a termination signal, runs the
+// reload hooks on SIGHUP and exits immediately on a second termination signal
+// during the shutdown. Then it runs the shutdown hooks in reverse registration
+// order, stops the services and the components and returns a *ShutdownError if
+// any of these steps failed or timed out.
+func Run(appName string, opts ...Option) error {
 	ctx := context.Background()
 
 	logCtx, logClean := log.NewCtx(ctx, appName, true)
@@ -16,19 +25,67 @@ func Run(appName string, opts ...Option) {
 
 	log.Info(logCtx, "Starting application "+appName)
 
+	if cfg.waitFunc != nil && len(cfg.reloadHooks) > 0 {
+		log.Warn(logCtx, "Reload hooks are not run with a custom wait function")
+	}
+
+	if cfg.probeAddr != "" {
+		ps, err := startProbeServer(logCtx, cfg.probeAddr, cfg.probes)
+		if err != nil {
+			cfg.cancel()
+
+			return err
+		}
+
+		cfg.probeServer = ps
+	}
+
+	// a boot failure runs the shutdown hooks registered so far, stops the
+	// started components and the probe server within the shutdown timeout
+	if err := bootComponents(cfg); err != nil {
+		return errors.Join(err, shutdown(logCtx, cfg))
+	}
+
+	cfg.probes.expect(countServices(cfg))
+
 	startServices(cfg)
+	startSupervised(cfg)
 
 	log.Info(logCtx, "Application "+appName+" is ready!")
 
-	wait(cfg)
+	abortErr := wait(cfg)
+	if abortErr != nil {
+		log.Err(logCtx, "Application "+appName+" is aborted", log.Error(abortErr))
+	}
+
+	stopForceExit := forceExit(cfg)
+	defer stopForceExit()
 
 	log.Info(logCtx, "Stopping application "+appName)
 
-	onShutdown(cfg)
-
-	gracefulStop(cfg)
+	err := shutdown(logCtx, cfg)
 
 	log.Info(logCtx, "Application "+appName+" is stopped!")
+
+	return errors.Join(abortErr, err)
+}
+
+func countServices(cfg *Config) int {
+	n := 0
+
+	for _, f := range cfg.services {
+		if f != nil {
+			n++
+		}
+	}
+
+	for _, s := range cfg.supervised {
+		if s.f != nil {
+			n++
+		}
+	}
+
+	return n
 }
 
 func startServices(cfg *Config) {
@@ -37,29 +94,74 @@ func startServices(cfg *Config) {
 		if f != nil {
 			cfg.wg.Add(1)
 
+			ctx := cfg.probes.withStarted(cfg.ctx)
+
 			go func() {
-				f(cfg.ctx)
+				f(ctx)
 				cfg.wg.Done()
 			}()
 		}
 	}
 }
 
-func wait(cfg *Config) {
-	cfg.waitFunc()
-}
+func wait(cfg *Config) error {
+	done := make(chan struct{})
 
-func onShutdown(cfg *Config) {
-	for _, f := range cfg.shutdownHooks {
-		if f != nil {
-			f(cfg.ctx)
+	go func() {
+		if cfg.waitFunc != nil {
+			cfg.waitFunc()
+		} else {
+			waitSignals(cfg)
 		}
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case err := <-cfg.aborted:
+		return err
 	}
 }
 
-func gracefulStop(cfg *Config) {
-	cfg.wg.Wait()
-	if cfg.cancel != nil {
-		cfg.cancel()
+func shutdown(ctx context.Context, cfg *Config) error {
+	ctx = context.WithoutCancel(ctx)
+
+	if cfg.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, cfg.shutdownTimeout)
+		defer cancel()
+	}
+
+	drain(ctx, cfg)
+
+	results := onShutdown(ctx, cfg)
+	results = append(results, gracefulStop(ctx, cfg))
+	results = append(results, stopComponents(ctx, cfg)...)
+
+	if cfg.probeServer != nil {
+		results = append(results, cfg.probeServer.stop(ctx))
+	}
+
+	return shutdownSummary(ctx, results)
+}
+
+// drain reports not ready and gives load balancers time to notice it before
+// the shutdown hooks run.
+func drain(ctx context.Context, cfg *Config) {
+	cfg.probes.drain()
+
+	if cfg.drainDelay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(cfg.drainDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
 	}
 }
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func noWait() {}
+
+func TestShutdownOrder(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+
+	hook := func(name string) HookFunc {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			order = append(order, name)
+
+			return nil
+		}
+	}
+
+	err := Run("test",
+		WithWaitFunc(noWait),
+		WithShutdownHook("first", 0, hook("first")),
+		WithShutdownHook("second", 0, hook("second")),
+		WithShutdownHook("third", 0, hook("third")),
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"third", "second", "first"}, order)
+}
+
+func TestShutdownErrors(t *testing.T) {
+	errHook := errors.New("hook error")
+
+	err := Run("test",
+		WithWaitFunc(noWait),
+		WithShutdownTimeout(time.Second),
+		WithShutdownHook("failing", 0, func(context.Context) error {
+			return errHook
+		}),
+		WithShutdownHook("slow", 10*time.Millisecond, func(ctx context.Context) error {
+			<-time.After(time.Minute)
+
+			return nil
+		}),
+		WithShutdownHook("panic", 0, func(context.Context) error {
+			panic("boom")
+		}),
+		WithShutdownHooks(func(context.Context) {}),
+	)
+
+	var shErr *ShutdownError
+
+	require.ErrorAs(t, err, &shErr)
+	require.Len(t, shErr.Failed, 3)
+	require.Equal(t, "panic", shErr.Failed[0].Name)
+	require.ErrorIs(t, shErr.Failed[0].Err, ErrHookPanic)
+	require.Equal(t, "slow", shErr.Failed[1].Name)
+	require.True(t, shErr.Failed[1].TimedOut())
+	require.Equal(t, "failing", shErr.Failed[2].Name)
+	require.ErrorIs(t, err, errHook)
+}
+
+func TestShutdownServicesTimeout(t *testing.T) {
+	err := Run("test",
+		WithWaitFunc(noWait),
+		WithShutdownTimeout(20*time.Millisecond),
+		WithServices(func(context.Context) {
+			<-time.After(time.Minute)
+		}),
+	)
+
+	var shErr *ShutdownError
+
+	require.ErrorAs(t, err, &shErr)
+	require.Len(t, shErr.Failed, 1)
+	require.Equal(t, servicesStepName, shErr.Failed[0].Name)
+	require.ErrorIs(t, err, ErrShutdownTimeout)
+}
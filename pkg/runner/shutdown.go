@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+const (
+	DefaultShutdownTimeout = 30 * time.Second
+
+	servicesStepName = "services"
+)
+
+var (
+	ErrShutdownTimeout = errors.New("shutdown timeout exceeded")
+	ErrHookPanic       = errors.New("shutdown hook panic")
+)
+
+// HookFunc is a shutdown hook. The context is cancelled when the hook
+// deadline or the global shutdown deadline expires.
+type HookFunc func(ctx context.Context) error
+
+type shutdownHook struct {
+	name    string
+	timeout time.Duration
+	f       HookFunc
+}
+
+// HookResult describes the outcome of a single shutdown step.
+type HookResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// TimedOut reports whether the step was abandoned because of a deadline.
+func (r HookResult) TimedOut() bool {
+	return errors.Is(r.Err, ErrShutdownTimeout)
+}
+
+// ShutdownError is returned by Run when some shutdown steps failed or timed out.
+type ShutdownError struct {
+	Failed []HookResult
+}
+
+func (e *ShutdownError) Error() string {
+	parts := make([]string, 0, len(e.Failed))
+
+	for _, r := range e.Failed {
+		parts = append(parts, fmt.Sprintf("%s: %v", r.Name, r.Err))
+	}
+
+	return "shutdown failed: " + strings.Join(parts, "; ")
+}
+
+func (e *ShutdownError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+
+	for _, r := range e.Failed {
+		errs = append(errs, r.Err)
+	}
+
+	return errs
+}
+
+// onShutdown runs hooks in reverse registration order, each one bounded by its
+// own timeout and by the global shutdown deadline.
+func onShutdown(ctx context.Context, cfg *Config) []HookResult {
+	results := make([]HookResult, 0, len(cfg.shutdownHooks))
+
+	for i := len(cfg.shutdownHooks) - 1; i >= 0; i-- {
+		h := cfg.shutdownHooks[i]
+		if h.f == nil {
+			continue
+		}
+
+		timeout := h.timeout
+		if timeout <= 0 {
+			timeout = cfg.hookTimeout
+		}
+
+		r := runHook(ctx, h.name, timeout, h.f)
+		if r.Err != nil {
+			log.Err(ctx, "shutdown hook failed",
+				log.String("hook", r.Name),
+				log.Duration("duration", r.Duration),
+				log.Error(r.Err))
+		} else {
+			log.Debug(ctx, "shutdown hook completed",
+				log.String("hook", r.Name),
+				log.Duration("duration", r.Duration))
+		}
+
+		results = append(results, r)
+	}
+
+	return results
+}
+
+func runHook(ctx context.Context, name string, timeout time.Duration, f HookFunc) HookResult {
+	var cancel context.CancelFunc
+
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	started := time.Now()
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- fmt.Errorf("%w: %v", ErrHookPanic, rec)
+			}
+		}()
+
+		done <- f(ctx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("%w: %w", ErrShutdownTimeout, ctx.Err())
+	}
+
+	return HookResult{Name: name, Err: err, Duration: time.Since(started)}
+}
+
+// gracefulStop cancels the application context and waits for the services to
+// return until the shutdown deadline expires.
+func gracefulStop(ctx context.Context, cfg *Config) HookResult {
+	started := time.Now()
+
+	if cfg.cancel != nil {
+		cfg.cancel()
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		cfg.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("%w: %w", ErrShutdownTimeout, ctx.Err())
+	}
+
+	return HookResult{Name: servicesStepName, Err: err, Duration: time.Since(started)}
+}
+
+func shutdownSummary(ctx context.Context, results []HookResult) error {
+	var failed []HookResult
+
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(failed))
+	timedOut := make([]string, 0, len(failed))
+
+	for _, r := range failed {
+		if r.TimedOut() {
+			timedOut = append(timedOut, r.Name)
+		} else {
+			names = append(names, r.Name)
+		}
+	}
+
+	log.Err(ctx, "shutdown completed with errors",
+		log.Strings("failed", names),
+		log.Strings("timedOut", timedOut))
+
+	return &ShutdownError{Failed: failed}
+}
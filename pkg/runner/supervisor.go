@@ -0,0 +1,181 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0wnperception/go-helpers/pkg/log"
+)
+
+const (
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+var (
+	ErrServicePanic          = errors.New("service panic")
+	ErrRestartsExhausted     = errors.New("service restarts exhausted")
+	ErrCriticalServiceFailed = errors.New("critical service failed")
+)
+
+type RestartPolicy int
+
+const (
+	// RestartNever runs the service once.
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts the service when it returns an error or panics.
+	RestartOnFailure
+	// RestartAlways restarts the service whenever it returns before shutdown.
+	RestartAlways
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartAlways:
+		return "always"
+	default:
+		return fmt.Sprintf("RestartPolicy(%d)", int(p))
+	}
+}
+
+// SupervisedFunc is a service run under supervision. It should block until ctx
+// is cancelled or the service fails.
+type SupervisedFunc func(ctx context.Context) error
+
+// Supervision describes how a supervised service is restarted.
+type Supervision struct {
+	Policy RestartPolicy
+	// MaxRestarts limits the number of restarts, zero means unlimited.
+	MaxRestarts int
+	// MinBackoff is the delay before the first restart, doubled on every next one.
+	MinBackoff time.Duration
+	// MaxBackoff caps the restart delay.
+	MaxBackoff time.Duration
+	// Critical services stop the whole application when they can not be restarted anymore.
+	Critical bool
+}
+
+type supervisedService struct {
+	name string
+	f    SupervisedFunc
+	spec Supervision
+}
+
+func startSupervised(cfg *Config) {
+	for i := range cfg.supervised {
+		s := cfg.supervised[i]
+		if s.f == nil {
+			continue
+		}
+
+		cfg.wg.Add(1)
+
+		ctx := cfg.probes.withStarted(cfg.ctx)
+
+		go func() {
+			defer cfg.wg.Done()
+
+			supervise(ctx, cfg, s)
+		}()
+	}
+}
+
+func supervise(ctx context.Context, cfg *Config, s supervisedService) {
+	ctx = log.WithFields(ctx, log.String("service", s.name))
+	restarts := 0
+
+	for {
+		err := runSupervised(ctx, s.f)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			log.Err(ctx, "service failed", log.Int("restarts", restarts), log.Error(err))
+		} else {
+			log.Warn(ctx, "service returned", log.Int("restarts", restarts))
+		}
+
+		if !shouldRestart(s.spec.Policy, err) {
+			if err != nil && s.spec.Critical {
+				cfg.abort(fmt.Errorf("%w: %s: %w", ErrCriticalServiceFailed, s.name, err))
+			}
+
+			return
+		}
+
+		if s.spec.MaxRestarts > 0 && restarts >= s.spec.MaxRestarts {
+			log.Err(ctx, "service restarts exhausted", log.Int("restarts", restarts))
+
+			if s.spec.Critical {
+				cfg.abort(fmt.Errorf("%w: %s: %w", ErrCriticalServiceFailed, s.name, ErrRestartsExhausted))
+			}
+
+			return
+		}
+
+		delay := backoff(s.spec, restarts)
+		restarts++
+
+		log.Info(ctx, "restarting service", log.Int("restart", restarts), log.Duration("backoff", delay))
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		}
+	}
+}
+
+func runSupervised(ctx context.Context, f SupervisedFunc) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("%w: %v", ErrServicePanic, rec)
+		}
+	}()
+
+	return f(ctx)
+}
+
+func shouldRestart(p RestartPolicy, err error) bool {
+	switch p {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+func backoff(spec Supervision, restarts int) time.Duration {
+	lo, hi := spec.MinBackoff, spec.MaxBackoff
+	if lo <= 0 {
+		lo = DefaultMinBackoff
+	}
+
+	if hi <= 0 {
+		hi = DefaultMaxBackoff
+	}
+
+	d := lo
+	for range restarts {
+		d *= 2
+		if d >= hi {
+			return hi
+		}
+	}
+
+	return min(d, hi)
+}
@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// blockWait returns a wait function blocking until the end of the test, so
+// Run only stops when it is aborted.
+func blockWait(t *testing.T) WaitFunc {
+	t.Helper()
+
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+
+	return func() { <-done }
+}
+
+func TestSupervisorRestartOnFailure(t *testing.T) {
+	var calls atomic.Int32
+
+	err := Run("test",
+		WithWaitFunc(blockWait(t)),
+		WithSupervisedService("flapping", func(context.Context) error {
+			calls.Add(1)
+
+			return errors.New("failed")
+		}, Supervision{
+			Policy:      RestartOnFailure,
+			MaxRestarts: 3,
+			MinBackoff:  time.Millisecond,
+			Critical:    true,
+		}),
+	)
+
+	require.ErrorIs(t, err, ErrCriticalServiceFailed)
+	require.ErrorIs(t, err, ErrRestartsExhausted)
+	require.Equal(t, int32(4), calls.Load())
+}
+
+func TestSupervisorPanic(t *testing.T) {
+	err := Run("test",
+		WithWaitFunc(blockWait(t)),
+		WithSupervisedService("panicking", func(context.Context) error {
+			panic("boom")
+		}, Supervision{Policy: RestartNever, Critical: true}),
+	)
+
+	require.ErrorIs(t, err, ErrServicePanic)
+}
+
+func TestSupervisorStopsOnShutdown(t *testing.T) {
+	var calls atomic.Int32
+
+	err := Run("test",
+		WithWaitFunc(func() { time.Sleep(20 * time.Millisecond) }),
+		WithSupervisedService("worker", func(ctx context.Context) error {
+			calls.Add(1)
+			<-ctx.Done()
+
+			return nil
+		}, Supervision{Policy: RestartAlways}),
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestBackoff(t *testing.T) {
+	spec := Supervision{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	require.Equal(t, time.Second, backoff(spec, 0))
+	require.Equal(t, 2*time.Second, backoff(spec, 1))
+	require.Equal(t, 4*time.Second, backoff(spec, 2))
+	require.Equal(t, 5*time.Second, backoff(spec, 3))
+	require.Equal(t, 5*time.Second, backoff(spec, 100))
+}
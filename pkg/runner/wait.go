@@ -1,24 +1,41 @@
 package runner
 
 import (
-	"os"
-	"os/signal"
-	"syscall"
+	"github.com/0wnperception/go-helpers/pkg/log"
+	sig "github.com/0wnperception/go-helpers/pkg/signal"
 )
 
-const signalChannelSize = 2
+const forceExitCode = 1
 
+// DefaultWaitFunc blocks until a termination signal is received, SIGHUP is
+// ignored. As any wait function it does not run the reload hooks, use
+// WithSignalWait for them.
 func DefaultWaitFunc() {
-	sigint := make(chan os.Signal, signalChannelSize)
-	defer close(sigint)
+	sig.Wait()
+}
+
+// waitSignals is used when no WaitFunc is set. SIGHUP runs the reload hooks
+// if there are any, it never stops the application.
+func waitSignals(cfg *Config) {
+	var onReload func()
 
-	signal.Notify(sigint,
-		os.Interrupt,
-		syscall.SIGTERM,
-		syscall.SIGQUIT,
-		syscall.SIGHUP)
+	if len(cfg.reloadHooks) > 0 {
+		onReload = func() {
+			reload(cfg)
+		}
+	}
+
+	if s := sig.WaitReload(onReload); s != nil {
+		log.Info(cfg.ctx, "Received signal "+s.String())
+	}
+}
 
-	<-sigint
+// forceExit makes a second termination signal exit immediately when the
+// signals are handled by the runner.
+func forceExit(cfg *Config) (stop func()) {
+	if cfg.waitFunc != nil {
+		return func() {}
+	}
 
-	signal.Stop(sigint)
+	return sig.ForceExit(forceExitCode)
 }
@@ -0,0 +1,112 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// guardSignals keeps SIGHUP and SIGTERM from reaching the default handlers and
+// returns the channel receiving them.
+func guardSignals(t *testing.T) <-chan os.Signal {
+	t.Helper()
+
+	guard := make(chan os.Signal, 4)
+	signal.Notify(guard, syscall.SIGHUP, syscall.SIGTERM)
+	t.Cleanup(func() { signal.Stop(guard) })
+
+	return guard
+}
+
+// requireStoppedBySIGTERM checks that SIGHUP does not stop the wait signalling
+// done and SIGTERM does.
+func requireStoppedBySIGTERM[T any](t *testing.T, guard <-chan os.Signal, done <-chan T) {
+	t.Helper()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	require.Equal(t, syscall.SIGHUP, <-guard)
+
+	select {
+	case <-done:
+		t.Fatal("SIGHUP stopped the wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGTERM did not stop the wait")
+	}
+}
+
+func TestReloadWithSignalWait(t *testing.T) {
+	guard := guardSignals(t)
+
+	var reloads atomic.Int32
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- Run("test",
+			WithWaitFunc(noWait),
+			WithSignalWait(),
+			WithReloadFuncs(func(context.Context) error {
+				reloads.Add(1)
+
+				return nil
+			}),
+		)
+	}()
+
+	require.Eventually(t, func() bool {
+		_ = syscall.Kill(os.Getpid(), syscall.SIGHUP)
+
+		return reloads.Load() > 0
+	}, 5*time.Second, 20*time.Millisecond, "SIGHUP runs the reload hooks")
+
+	requireStoppedBySIGTERM(t, guard, done)
+}
+
+func TestSIGHUPWithoutReloadHooks(t *testing.T) {
+	guard := guardSignals(t)
+
+	started := make(chan struct{})
+	done := make(chan error, 1)
+
+	go func() {
+		done <- Run("test", WithServices(func(context.Context) {
+			close(started)
+		}))
+	}()
+
+	<-started
+	// lets Run reach the signal wait after starting the services
+	time.Sleep(20 * time.Millisecond)
+
+	requireStoppedBySIGTERM(t, guard, done)
+}
+
+func TestDefaultWaitFuncIgnoresSIGHUP(t *testing.T) {
+	guard := guardSignals(t)
+
+	done := make(chan struct{})
+
+	go func() {
+		DefaultWaitFunc()
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	requireStoppedBySIGTERM(t, guard, done)
+}
@@ -8,17 +8,61 @@ import (
 
 const signalChannelSize = 2
 
+// TerminationSignals are the signals that stop the application.
+var TerminationSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGTERM,
+	syscall.SIGQUIT,
+}
+
+// Wait blocks until one of TerminationSignals is received, SIGHUP is ignored.
 func Wait() {
+	WaitReload(nil)
+}
+
+// WaitReload blocks until one of TerminationSignals is received and returns it.
+// Every SIGHUP calls reload, if any, instead of stopping.
+func WaitReload(reload func()) os.Signal {
 	sigint := make(chan os.Signal, signalChannelSize)
 	defer close(sigint)
 
-	signal.Notify(sigint,
-		os.Interrupt,
-		syscall.SIGTERM,
-		syscall.SIGQUIT,
-		syscall.SIGHUP)
+	signal.Notify(sigint, TerminationSignals...)
+	signal.Notify(sigint, syscall.SIGHUP)
+	defer signal.Stop(sigint)
+
+	for s := range sigint {
+		if s == syscall.SIGHUP {
+			if reload != nil {
+				reload()
+			}
+
+			continue
+		}
+
+		return s
+	}
+
+	return nil
+}
+
+// ForceExit exits the process with code as soon as one more termination
+// signal is received. The returned function stops watching.
+func ForceExit(code int) (stop func()) {
+	sigint := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	signal.Notify(sigint, TerminationSignals...)
 
-	<-sigint
+	go func() {
+		select {
+		case <-sigint:
+			os.Exit(code)
+		case <-done:
+		}
+	}()
 
-	signal.Stop(sigint)
+	return func() {
+		signal.Stop(sigint)
+		close(done)
+	}
 }
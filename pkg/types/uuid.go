@@ -2,6 +2,7 @@
 package types
 
 import (
+	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -63,6 +64,20 @@ func NewUUID(b []byte) (UUID, error) {
 	return r, nil
 }
 
+// NewUUIDv4 returns a random UUID of version 4.
+func NewUUIDv4() (UUID, error) {
+	var u UUID
+
+	if _, err := rand.Read(u[:]); err != nil {
+		return UUID{}, fmt.Errorf("read random error: %w", err)
+	}
+
+	u.SetVersion(4)
+	u.SetVariant(VariantRFC4122)
+
+	return u, nil
+}
+
 func NewUUIDFromString(s string) (UUID, error) {
 	var uuid UUID
 
@@ -141,3 +141,19 @@ func TestOptUUiD_LogValue(t *testing.T) {
 
 	assert.Equal(t, slog.KindString, val.Kind())
 }
+
+func TestNewUUIDv4(t *testing.T) {
+	u, err := NewUUIDv4()
+	require.NoError(t, err)
+
+	require.Equal(t, byte(4), u[6]>>4)
+	require.Equal(t, byte(0x02), u[8]>>6)
+
+	parsed, err := NewUUIDFromString(u.String())
+	require.NoError(t, err)
+	require.Equal(t, u, parsed)
+
+	other, err := NewUUIDv4()
+	require.NoError(t, err)
+	require.NotEqual(t, u, other)
+}